@@ -0,0 +1,138 @@
+package admin_api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"code.cloudfoundry.org/cf-tcp-router/config"
+	"code.cloudfoundry.org/cf-tcp-router/models"
+	"code.cloudfoundry.org/cf-tcp-router/routing_table"
+	"code.cloudfoundry.org/lager/v3"
+	routing_api_models "code.cloudfoundry.org/routing-api/models"
+	"code.cloudfoundry.org/tlsconfig"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/http_server"
+)
+
+const RoutingTablePath = "/routing_table"
+
+type RoutingTableResponse struct {
+	LastSyncTime *time.Time `json:"last_sync_time"`
+	Syncing      bool       `json:"syncing"`
+	Ready        bool       `json:"ready"`
+	Draining     bool       `json:"draining"`
+	Routes       []Route    `json:"routes"`
+}
+
+type Route struct {
+	Port        uint16    `json:"port"`
+	SniHostname string    `json:"sni_hostname,omitempty"`
+	Backends    []Backend `json:"backends"`
+}
+
+type Backend struct {
+	Address         string                             `json:"address"`
+	Port            uint16                             `json:"port"`
+	TLSPort         int                                `json:"tls_port,omitempty"`
+	InstanceID      string                             `json:"instance_id,omitempty"`
+	ModificationTag routing_api_models.ModificationTag `json:"modification_tag"`
+	TTL             int                                `json:"ttl"`
+	UpdatedTime     time.Time                          `json:"updated_time"`
+}
+
+// NewServer returns an HTTPS server for the admin API that only accepts
+// clients presenting a certificate signed by the configured CA.
+func NewServer(logger lager.Logger, cfg config.AdminAPIConfig, updater routing_table.Updater) (ifrit.Runner, error) {
+	tlsConfig, err := tlsconfig.Build(
+		tlsconfig.WithInternalServiceDefaults(),
+		tlsconfig.WithIdentityFromFile(cfg.CertPath, cfg.KeyPath),
+	).Server(
+		tlsconfig.WithClientAuthenticationFromFile(cfg.ClientCACertPath),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	address := fmt.Sprintf(":%d", cfg.Port)
+	return http_server.NewTLSServer(address, NewHandler(logger, updater), tlsConfig), nil
+}
+
+func NewHandler(logger lager.Logger, updater routing_table.Updater) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(RoutingTablePath, &routingTableHandler{
+		logger:  logger.Session("admin-api"),
+		updater: updater,
+	})
+	return mux
+}
+
+type routingTableHandler struct {
+	logger  lager.Logger
+	updater routing_table.Updater
+}
+
+func (h *routingTableHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := RoutingTableResponse{
+		Syncing:  h.updater.Syncing(),
+		Ready:    h.updater.Ready(),
+		Draining: h.updater.IsDraining(),
+		Routes:   toRoutes(h.updater.RoutingTable()),
+	}
+	if lastSyncTime := h.updater.LastSyncTime(); !lastSyncTime.IsZero() {
+		response.LastSyncTime = &lastSyncTime
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		h.logger.Error("failed-to-encode-routing-table", err)
+	}
+}
+
+func toRoutes(routingTable models.RoutingTable) []Route {
+	routes := make([]Route, 0, len(routingTable.Entries))
+	for routingKey, entry := range routingTable.Entries {
+		route := Route{
+			Port:        routingKey.Port,
+			SniHostname: string(routingKey.SniHostname),
+			Backends:    make([]Backend, 0, len(entry.Backends)),
+		}
+		for key, details := range entry.Backends {
+			route.Backends = append(route.Backends, Backend{
+				Address:         key.Address,
+				Port:            key.Port,
+				TLSPort:         key.TLSPort,
+				InstanceID:      key.InstanceID,
+				ModificationTag: details.ModificationTag,
+				TTL:             details.TTL,
+				UpdatedTime:     details.UpdatedTime,
+			})
+		}
+
+		// Sort backends by address, then port, to match the generated config
+		sort.Slice(route.Backends, func(i, j int) bool {
+			if route.Backends[i].Address == route.Backends[j].Address {
+				return route.Backends[i].Port < route.Backends[j].Port
+			}
+			return route.Backends[i].Address < route.Backends[j].Address
+		})
+		routes = append(routes, route)
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Port == routes[j].Port {
+			return routes[i].SniHostname < routes[j].SniHostname
+		}
+		return routes[i].Port < routes[j].Port
+	})
+	return routes
+}
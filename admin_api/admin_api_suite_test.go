@@ -0,0 +1,22 @@
+package admin_api_test
+
+import (
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+var (
+	logger *lagertest.TestLogger
+)
+
+func TestAdminAPI(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AdminAPI Suite")
+}
+
+var _ = BeforeEach(func() {
+	logger = lagertest.NewTestLogger("test")
+})
@@ -0,0 +1,136 @@
+package admin_api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"code.cloudfoundry.org/cf-tcp-router/admin_api"
+	"code.cloudfoundry.org/cf-tcp-router/config"
+	"code.cloudfoundry.org/cf-tcp-router/models"
+	"code.cloudfoundry.org/cf-tcp-router/routing_table/fakes"
+	routing_api_models "code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AdminAPI", func() {
+	var (
+		fakeUpdater *fakes.FakeUpdater
+		handler     http.Handler
+		recorder    *httptest.ResponseRecorder
+		updatedTime time.Time
+	)
+
+	BeforeEach(func() {
+		fakeUpdater = new(fakes.FakeUpdater)
+		recorder = httptest.NewRecorder()
+		updatedTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		routingTable := models.NewRoutingTable(logger)
+		routingTable.Entries[models.RoutingKey{Port: 2000, SniHostname: "sni.example.com"}] = models.RoutingTableEntry{
+			Backends: map[models.BackendServerKey]models.BackendServerDetails{
+				{Address: "10.0.0.2", Port: 8080, TLSPort: 8443, InstanceID: "instance-2"}: {
+					ModificationTag: routing_api_models.ModificationTag{Guid: "guid-2", Index: 3},
+					TTL:             60,
+					UpdatedTime:     updatedTime,
+				},
+			},
+		}
+		routingTable.Entries[models.RoutingKey{Port: 1000}] = models.RoutingTableEntry{
+			Backends: map[models.BackendServerKey]models.BackendServerDetails{
+				{Address: "10.0.0.1", Port: 9090}: {
+					ModificationTag: routing_api_models.ModificationTag{Guid: "guid-1", Index: 1},
+					TTL:             120,
+					UpdatedTime:     updatedTime,
+				},
+				{Address: "10.0.0.1", Port: 8080}: {
+					ModificationTag: routing_api_models.ModificationTag{Guid: "guid-1", Index: 2},
+					TTL:             120,
+					UpdatedTime:     updatedTime,
+				},
+			},
+		}
+		fakeUpdater.RoutingTableReturns(routingTable)
+
+		handler = admin_api.NewHandler(logger, fakeUpdater)
+	})
+
+	Describe("GET /routing_table", func() {
+		It("returns the routing table sorted by port and hostname", func() {
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, admin_api.RoutingTablePath, nil))
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Header().Get("Content-Type")).To(Equal("application/json"))
+
+			var response admin_api.RoutingTableResponse
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+			Expect(response.Routes).To(Equal([]admin_api.Route{
+				{
+					Port: 1000,
+					Backends: []admin_api.Backend{
+						{Address: "10.0.0.1", Port: 8080, ModificationTag: routing_api_models.ModificationTag{Guid: "guid-1", Index: 2}, TTL: 120, UpdatedTime: updatedTime},
+						{Address: "10.0.0.1", Port: 9090, ModificationTag: routing_api_models.ModificationTag{Guid: "guid-1", Index: 1}, TTL: 120, UpdatedTime: updatedTime},
+					},
+				},
+				{
+					Port:        2000,
+					SniHostname: "sni.example.com",
+					Backends: []admin_api.Backend{
+						{Address: "10.0.0.2", Port: 8080, TLSPort: 8443, InstanceID: "instance-2", ModificationTag: routing_api_models.ModificationTag{Guid: "guid-2", Index: 3}, TTL: 60, UpdatedTime: updatedTime},
+					},
+				},
+			}))
+		})
+
+		It("includes the sync and drain state", func() {
+			lastSyncTime := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+			fakeUpdater.LastSyncTimeReturns(lastSyncTime)
+			fakeUpdater.SyncingReturns(true)
+			fakeUpdater.ReadyReturns(true)
+			fakeUpdater.IsDrainingReturns(true)
+
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, admin_api.RoutingTablePath, nil))
+
+			var response admin_api.RoutingTableResponse
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+			Expect(response.LastSyncTime).To(HaveValue(Equal(lastSyncTime)))
+			Expect(response.Syncing).To(BeTrue())
+			Expect(response.Ready).To(BeTrue())
+			Expect(response.Draining).To(BeTrue())
+		})
+
+		Context("when routes have never been synced", func() {
+			It("returns a null last sync time", func() {
+				handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, admin_api.RoutingTablePath, nil))
+
+				Expect(recorder.Body.String()).To(ContainSubstring(`"last_sync_time":null`))
+			})
+		})
+	})
+
+	Context("when the method is not GET", func() {
+		It("returns 405", func() {
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, admin_api.RoutingTablePath, nil))
+
+			Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+			Expect(fakeUpdater.RoutingTableCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("NewServer", func() {
+		Context("when the certificates cannot be loaded", func() {
+			It("returns an error", func() {
+				_, err := admin_api.NewServer(logger, config.AdminAPIConfig{
+					Enabled:          true,
+					Port:             8444,
+					CertPath:         "does/not/exist.crt",
+					KeyPath:          "does/not/exist.key",
+					ClientCACertPath: "does/not/exist.ca",
+				}, fakeUpdater)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
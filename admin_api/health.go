@@ -0,0 +1,64 @@
+package admin_api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"code.cloudfoundry.org/cf-tcp-router/config"
+	"code.cloudfoundry.org/cf-tcp-router/routing_table"
+	"code.cloudfoundry.org/lager/v3"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/http_server"
+)
+
+const HealthPath = "/health"
+
+type HealthResponse struct {
+	Ready    bool `json:"ready"`
+	Draining bool `json:"draining"`
+}
+
+// NewHealthServer returns a plain HTTP server for load balancers in front of
+// the router. It reports healthy once the first sync of routes has been
+// applied, and unhealthy again while draining.
+func NewHealthServer(logger lager.Logger, cfg config.ReadinessConfig, updater routing_table.Updater) ifrit.Runner {
+	address := fmt.Sprintf(":%d", cfg.Port)
+	return http_server.New(address, NewHealthHandler(logger, updater))
+}
+
+func NewHealthHandler(logger lager.Logger, updater routing_table.Updater) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(HealthPath, &healthHandler{
+		logger:  logger.Session("health"),
+		updater: updater,
+	})
+	return mux
+}
+
+type healthHandler struct {
+	logger  lager.Logger
+	updater routing_table.Updater
+}
+
+func (h *healthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := HealthResponse{
+		Ready:    h.updater.Ready(),
+		Draining: h.updater.IsDraining(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !response.Ready || response.Draining {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		h.logger.Error("failed-to-encode-health", err)
+	}
+}
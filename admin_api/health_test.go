@@ -0,0 +1,76 @@
+package admin_api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/cf-tcp-router/admin_api"
+	"code.cloudfoundry.org/cf-tcp-router/routing_table/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Health", func() {
+	var (
+		fakeUpdater *fakes.FakeUpdater
+		handler     http.Handler
+		recorder    *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		fakeUpdater = new(fakes.FakeUpdater)
+		recorder = httptest.NewRecorder()
+		handler = admin_api.NewHealthHandler(logger, fakeUpdater)
+	})
+
+	healthResponse := func() admin_api.HealthResponse {
+		var response admin_api.HealthResponse
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+		return response
+	}
+
+	Context("before the first sync has been applied", func() {
+		It("returns 503", func() {
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, admin_api.HealthPath, nil))
+
+			Expect(recorder.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(healthResponse()).To(Equal(admin_api.HealthResponse{Ready: false}))
+		})
+	})
+
+	Context("once the first sync has been applied", func() {
+		BeforeEach(func() {
+			fakeUpdater.ReadyReturns(true)
+		})
+
+		It("returns 200", func() {
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, admin_api.HealthPath, nil))
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Header().Get("Content-Type")).To(Equal("application/json"))
+			Expect(healthResponse()).To(Equal(admin_api.HealthResponse{Ready: true}))
+		})
+
+		Context("when the router is draining", func() {
+			BeforeEach(func() {
+				fakeUpdater.IsDrainingReturns(true)
+			})
+
+			It("returns 503", func() {
+				handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, admin_api.HealthPath, nil))
+
+				Expect(recorder.Code).To(Equal(http.StatusServiceUnavailable))
+				Expect(healthResponse()).To(Equal(admin_api.HealthResponse{Ready: true, Draining: true}))
+			})
+		})
+	})
+
+	Context("when the method is not GET", func() {
+		It("returns 405", func() {
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, admin_api.HealthPath, nil))
+
+			Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+})
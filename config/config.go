@@ -7,6 +7,7 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"strings"
 	"time"
@@ -39,17 +40,368 @@ type BackendTLSConfig struct {
 	ClientCertAndKeyPath string `yaml:"client_cert_and_key_path"`
 }
 
+type RuntimeAPIConfig struct {
+	Enabled     bool `yaml:"enabled"`
+	ServerSlots int  `yaml:"server_slots"`
+}
+
+type AdminAPIConfig struct {
+	Enabled          bool   `yaml:"enabled"`
+	Port             uint16 `yaml:"port"`
+	CertPath         string `yaml:"cert_path"`
+	KeyPath          string `yaml:"key_path"`
+	ClientCACertPath string `yaml:"client_ca_cert_path"`
+}
+
+// ReadinessConfig enables a plain HTTP endpoint on Port that reports whether
+// the router has applied its first full sync of routes, for load balancers in
+// front of the router.
+type ReadinessConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Port    uint16 `yaml:"port"`
+}
+
+// HealthCheckConfig enables active checks of backend servers. Servers are
+// checked with a TCP connect; TLSHandshake additionally completes a TLS
+// handshake with servers that are reached on their TLSPort.
+type HealthCheckConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	Interval     time.Duration `yaml:"interval"`
+	Rise         int           `yaml:"rise"`
+	Fall         int           `yaml:"fall"`
+	TLSHandshake bool          `yaml:"tls_handshake"`
+}
+
+type BalanceAlgorithm string
+
+const (
+	BalanceRoundRobin BalanceAlgorithm = "roundrobin"
+	BalanceLeastConn  BalanceAlgorithm = "leastconn"
+	BalanceSource     BalanceAlgorithm = "source"
+	BalanceFirst      BalanceAlgorithm = "first"
+)
+
+// LoadBalancingConfig selects how HAProxy balances connections between the
+// servers of a backend. Ports are balanced with Algorithm unless they have
+// an entry in Ports. HAProxy's default, roundrobin, is used when neither is
+// set.
+type LoadBalancingConfig struct {
+	Algorithm BalanceAlgorithm            `yaml:"algorithm"`
+	Ports     map[uint16]BalanceAlgorithm `yaml:"ports"`
+}
+
+// AlgorithmForPort returns the algorithm to balance a port with, or "" when
+// none is configured.
+func (c LoadBalancingConfig) AlgorithmForPort(port uint16) BalanceAlgorithm {
+	if algorithm, ok := c.Ports[port]; ok {
+		return algorithm
+	}
+	return c.Algorithm
+}
+
+func validateBalanceAlgorithm(algorithm BalanceAlgorithm) error {
+	switch algorithm {
+	case BalanceRoundRobin, BalanceLeastConn, BalanceSource, BalanceFirst:
+		return nil
+	default:
+		return fmt.Errorf("balance algorithm must be one of %q, %q, %q or %q, got %q", BalanceRoundRobin, BalanceLeastConn, BalanceSource, BalanceFirst, algorithm)
+	}
+}
+
+type ProxyProtocolVersion string
+
+const (
+	ProxyProtocolNone ProxyProtocolVersion = "none"
+	ProxyProtocolV1   ProxyProtocolVersion = "v1"
+	ProxyProtocolV2   ProxyProtocolVersion = "v2"
+)
+
+// ProxyProtocolRoute overrides the PROXY protocol version sent to the
+// servers of a single route. SniHostname is empty for the non-SNI route.
+type ProxyProtocolRoute struct {
+	Port        uint16               `yaml:"port"`
+	SniHostname string               `yaml:"sni_hostname"`
+	Version     ProxyProtocolVersion `yaml:"version"`
+}
+
+// ProxyProtocolConfig controls the PROXY protocol header that passes the
+// client address on. Accept expects the header on every frontend, for when
+// the router sits behind a load balancer that sends one. Send is the version
+// sent to backend servers, and can be overridden per port and per route,
+// with "none" turning it off.
+type ProxyProtocolConfig struct {
+	Accept bool                            `yaml:"accept"`
+	Send   ProxyProtocolVersion            `yaml:"send"`
+	Ports  map[uint16]ProxyProtocolVersion `yaml:"ports"`
+	Routes []ProxyProtocolRoute            `yaml:"routes"`
+}
+
+// VersionForRoute returns the version to send to the servers of a route, or
+// "" when none should be sent.
+func (c ProxyProtocolConfig) VersionForRoute(port uint16, sniHostname string) ProxyProtocolVersion {
+	version := c.Send
+	if portVersion, ok := c.Ports[port]; ok {
+		version = portVersion
+	}
+	for _, route := range c.Routes {
+		if route.Port == port && route.SniHostname == sniHostname {
+			version = route.Version
+		}
+	}
+	if version == ProxyProtocolNone {
+		return ""
+	}
+	return version
+}
+
+func validateProxyProtocolVersion(version ProxyProtocolVersion) error {
+	switch version {
+	case ProxyProtocolNone, ProxyProtocolV1, ProxyProtocolV2:
+		return nil
+	default:
+		return fmt.Errorf("proxy protocol version must be one of %q, %q or %q, got %q", ProxyProtocolNone, ProxyProtocolV1, ProxyProtocolV2, version)
+	}
+}
+
+// TimeoutProfile holds the timeouts and connection limits rendered into the
+// frontends and backends of the ports it is assigned to. Zero values leave
+// the setting from the base config in place.
+type TimeoutProfile struct {
+	Connect         time.Duration `yaml:"connect"`
+	Client          time.Duration `yaml:"client"`
+	Server          time.Duration `yaml:"server"`
+	Tunnel          time.Duration `yaml:"tunnel"`
+	ClientFin       time.Duration `yaml:"client_fin"`
+	FrontendMaxConn int           `yaml:"frontend_maxconn"`
+	ServerMaxConn   int           `yaml:"server_maxconn"`
+}
+
+// PortRange holds the ports from Start to End inclusive. End defaults to
+// Start.
+type PortRange struct {
+	Start uint16 `yaml:"start"`
+	End   uint16 `yaml:"end"`
+}
+
+func (r PortRange) Contains(port uint16) bool {
+	return port >= r.Start && port <= r.End
+}
+
+func (r *PortRange) validate() error {
+	if r.Start == 0 {
+		return errors.New("start is required")
+	}
+	if r.End == 0 {
+		r.End = r.Start
+	}
+	if r.End < r.Start {
+		return fmt.Errorf("end %d is before start %d", r.End, r.Start)
+	}
+	return nil
+}
+
+// PortRangeProfile assigns a timeout profile to a range of ports.
+type PortRangeProfile struct {
+	PortRange `yaml:",inline"`
+	Profile   string `yaml:"profile"`
+}
+
+type TimeoutsConfig struct {
+	Profiles map[string]TimeoutProfile `yaml:"profiles"`
+	Ports    []PortRangeProfile        `yaml:"ports"`
+}
+
+// ProfileForPort returns the profile of the first port range containing
+// port.
+func (c TimeoutsConfig) ProfileForPort(port uint16) (TimeoutProfile, bool) {
+	for _, portRange := range c.Ports {
+		if portRange.Contains(port) {
+			return c.Profiles[portRange.Profile], true
+		}
+	}
+	return TimeoutProfile{}, false
+}
+
+func (c *TimeoutsConfig) validate() error {
+	for name, profile := range c.Profiles {
+		if profile.Connect < 0 || profile.Client < 0 || profile.Server < 0 || profile.Tunnel < 0 || profile.ClientFin < 0 {
+			return fmt.Errorf("timeouts.profiles.%s: timeouts must not be negative", name)
+		}
+		if profile.FrontendMaxConn < 0 || profile.ServerMaxConn < 0 {
+			return fmt.Errorf("timeouts.profiles.%s: maxconn must not be negative", name)
+		}
+	}
+
+	for i := range c.Ports {
+		portRange := &c.Ports[i]
+		if err := portRange.validate(); err != nil {
+			return fmt.Errorf("timeouts.ports[%d]: %s", i, err)
+		}
+		if _, ok := c.Profiles[portRange.Profile]; !ok {
+			return fmt.Errorf("timeouts.ports[%d]: unknown profile %q", i, portRange.Profile)
+		}
+	}
+	return nil
+}
+
+// TLSTerminationConfig terminates client TLS on Ports with the certificates
+// in CertDir, which HAProxy selects by SNI. Traffic to backend servers is
+// plaintext unless they have a TLSPort and backend_tls is enabled.
+type TLSTerminationConfig struct {
+	CertDir string   `yaml:"cert_dir"`
+	Ports   []uint16 `yaml:"ports"`
+}
+
+func (c TLSTerminationConfig) Terminates(port uint16) bool {
+	for _, p := range c.Ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessRule restricts the clients that may connect to a range of ports.
+// Connections from Deny are rejected, and when Allow is set, so are
+// connections from anywhere else. Entries are CIDRs or IP addresses.
+type AccessRule struct {
+	PortRange `yaml:",inline"`
+	Allow     []string `yaml:"allow"`
+	Deny      []string `yaml:"deny"`
+}
+
+type AccessControlConfig struct {
+	Ports []AccessRule `yaml:"ports"`
+}
+
+// RuleForPort returns the first rule whose port range contains port.
+func (c AccessControlConfig) RuleForPort(port uint16) (AccessRule, bool) {
+	for _, rule := range c.Ports {
+		if rule.Contains(port) {
+			return rule, true
+		}
+	}
+	return AccessRule{}, false
+}
+
+func (c *AccessControlConfig) validate() error {
+	for i := range c.Ports {
+		rule := &c.Ports[i]
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("access_control.ports[%d]: %s", i, err)
+		}
+		for _, source := range append(append([]string{}, rule.Allow...), rule.Deny...) {
+			if _, _, err := net.ParseCIDR(source); err != nil && net.ParseIP(source) == nil {
+				return fmt.Errorf("access_control.ports[%d]: %q is not a CIDR or IP address", i, source)
+			}
+		}
+	}
+	return nil
+}
+
+// RateLimitRule limits, per client IP, how many connections may be opened to
+// a range of ports within RatePeriod and how many may be open at once. Zero
+// limits are not enforced.
+type RateLimitRule struct {
+	PortRange             `yaml:",inline"`
+	ConnectionRate        int           `yaml:"connection_rate"`
+	RatePeriod            time.Duration `yaml:"rate_period"`
+	ConcurrentConnections int           `yaml:"concurrent_connections"`
+}
+
+type RateLimitConfig struct {
+	// TableSize is the number of client IPs tracked by each frontend
+	TableSize int             `yaml:"table_size"`
+	Ports     []RateLimitRule `yaml:"ports"`
+}
+
+// RuleForPort returns the first rule whose port range contains port.
+func (c RateLimitConfig) RuleForPort(port uint16) (RateLimitRule, bool) {
+	for _, rule := range c.Ports {
+		if rule.Contains(port) {
+			return rule, true
+		}
+	}
+	return RateLimitRule{}, false
+}
+
+func (c *RateLimitConfig) validate() error {
+	if len(c.Ports) == 0 {
+		return nil
+	}
+	if c.TableSize <= 0 {
+		c.TableSize = RateLimitTableSizeDefault
+	}
+	for i := range c.Ports {
+		rule := &c.Ports[i]
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("rate_limit.ports[%d]: %s", i, err)
+		}
+		if rule.ConnectionRate < 0 || rule.ConcurrentConnections < 0 {
+			return fmt.Errorf("rate_limit.ports[%d]: limits must not be negative", i)
+		}
+		if rule.RatePeriod <= 0 {
+			rule.RatePeriod = RateLimitPeriodDefault
+		}
+	}
+	return nil
+}
+
+type MetricsEmitter string
+
+const (
+	DropsondeMetricsEmitter  MetricsEmitter = "dropsonde"
+	PrometheusMetricsEmitter MetricsEmitter = "prometheus"
+)
+
+type MetricsConfig struct {
+	Emitter        MetricsEmitter `yaml:"emitter"`
+	PrometheusPort uint16         `yaml:"prometheus_port"`
+}
+
+// FrontendIPFamily selects the address family frontends bind on.
+type FrontendIPFamily string
+
+const (
+	FrontendIPv4      FrontendIPFamily = "ipv4"
+	FrontendIPv6      FrontendIPFamily = "ipv6"
+	FrontendDualStack FrontendIPFamily = "dual_stack"
+)
+
 type Config struct {
-	OAuth                        OAuthConfig      `yaml:"oauth"`
-	RoutingAPI                   RoutingAPIConfig `yaml:"routing_api"`
-	HaProxyPidFile               string           `yaml:"haproxy_pid_file"`
-	IsolationSegments            []string         `yaml:"isolation_segments"`
-	ReservedSystemComponentPorts []uint16         `yaml:"reserved_system_component_ports"`
-	DrainWaitDuration            time.Duration    `yaml:"drain_wait"`
-	BackendTLS                   BackendTLSConfig `yaml:"backend_tls"`
+	OAuth                        OAuthConfig          `yaml:"oauth"`
+	RoutingAPI                   RoutingAPIConfig     `yaml:"routing_api"`
+	HaProxyPidFile               string               `yaml:"haproxy_pid_file"`
+	IsolationSegments            []string             `yaml:"isolation_segments"`
+	RouterGroups                 []string             `yaml:"router_groups"`
+	ReservedSystemComponentPorts []uint16             `yaml:"reserved_system_component_ports"`
+	DrainWaitDuration            time.Duration        `yaml:"drain_wait"`
+	BackendTLS                   BackendTLSConfig     `yaml:"backend_tls"`
+	RuntimeAPI                   RuntimeAPIConfig     `yaml:"runtime_api"`
+	FrontendIPFamily             FrontendIPFamily     `yaml:"frontend_ip_family"`
+	AdminAPI                     AdminAPIConfig       `yaml:"admin_api"`
+	Readiness                    ReadinessConfig      `yaml:"readiness"`
+	Metrics                      MetricsConfig        `yaml:"metrics"`
+	HealthCheck                  HealthCheckConfig    `yaml:"health_check"`
+	LoadBalancing                LoadBalancingConfig  `yaml:"load_balancing"`
+	ProxyProtocol                ProxyProtocolConfig  `yaml:"proxy_protocol"`
+	Timeouts                     TimeoutsConfig       `yaml:"timeouts"`
+	TLSTermination               TLSTerminationConfig `yaml:"tls_termination"`
+	AccessControl                AccessControlConfig  `yaml:"access_control"`
+	RateLimit                    RateLimitConfig      `yaml:"rate_limit"`
 }
 
-const DrainWaitDefault = 20 * time.Second
+const (
+	DrainWaitDefault   = 20 * time.Second
+	ServerSlotsDefault = 10
+
+	HealthCheckIntervalDefault = 2 * time.Second
+	HealthCheckRiseDefault     = 2
+	HealthCheckFallDefault     = 3
+
+	RateLimitTableSizeDefault = 100000
+	RateLimitPeriodDefault    = 10 * time.Second
+)
 
 func New(path string) (*Config, error) {
 	c := &Config{}
@@ -81,78 +433,236 @@ func (c *Config) initConfigFromFile(path string) error {
 		c.DrainWaitDuration = DrainWaitDefault
 	}
 
-	if c.BackendTLS.Enabled {
-		if c.BackendTLS.CACertificatePath != "" {
-			pemData, err := os.ReadFile(c.BackendTLS.CACertificatePath)
-			if err != nil {
-				return err
-			}
+	switch c.FrontendIPFamily {
+	case "", FrontendIPv4, FrontendIPv6, FrontendDualStack:
+	default:
+		return fmt.Errorf("frontend_ip_family must be one of %q, %q or %q, got %q", FrontendIPv4, FrontendIPv6, FrontendDualStack, c.FrontendIPFamily)
+	}
 
-			pemData = []byte(strings.TrimSpace(string(pemData)))
-			if len(pemData) > 0 {
-				var block *pem.Block
-				block, _ = pem.Decode(pemData)
-				if block == nil {
-					return fmt.Errorf("Invalid PEM block found in file %q", c.BackendTLS.CACertificatePath)
-				}
-				if len(block.Headers) != 0 {
-					return fmt.Errorf("Unexpected headers in PEM block in file %q: %v", c.BackendTLS.CACertificatePath, block.Headers)
-				}
-				if block.Type != "CERTIFICATE" {
-					return fmt.Errorf("Unexpected PEM block type %q in file %q (wanted CERTIFICATE)", block.Type, c.BackendTLS.CACertificatePath)
-				}
-				_, err = x509.ParseCertificate(block.Bytes)
-				if err != nil {
-					return fmt.Errorf("failed to parse certificate in %q: %s", c.BackendTLS.CACertificatePath, err)
-				}
-			}
-		} else {
-			return fmt.Errorf("Backend TLS was enabled but no CA certificates were specified")
+	switch c.Metrics.Emitter {
+	case "", DropsondeMetricsEmitter:
+	case PrometheusMetricsEmitter:
+		if c.Metrics.PrometheusPort == 0 {
+			return errors.New("metrics.prometheus_port is required when the prometheus emitter is selected")
 		}
+	default:
+		return fmt.Errorf("metrics.emitter must be %q or %q, got %q", DropsondeMetricsEmitter, PrometheusMetricsEmitter, c.Metrics.Emitter)
+	}
 
-		if c.BackendTLS.ClientCertAndKeyPath != "" {
-			pemData, err := os.ReadFile(c.BackendTLS.ClientCertAndKeyPath)
-			if err != nil {
-				return err
-			}
+	if c.AdminAPI.Enabled {
+		if c.AdminAPI.Port == 0 {
+			return errors.New("admin_api.port is required when the admin api is enabled")
+		}
+		if c.AdminAPI.CertPath == "" || c.AdminAPI.KeyPath == "" || c.AdminAPI.ClientCACertPath == "" {
+			return errors.New("admin_api.cert_path, admin_api.key_path and admin_api.client_ca_cert_path are required when the admin api is enabled")
+		}
+	}
 
-			pemData = []byte(strings.TrimSpace(string(pemData)))
-			var certBlock *pem.Block
-			certBlock, pemData = pem.Decode(pemData)
-			if certBlock == nil {
-				return fmt.Errorf("Invalid PEM CERTIFICATE found in file %q", c.BackendTLS.ClientCertAndKeyPath)
-			}
-			certPEM := bytes.NewBuffer([]byte{})
-			err = pem.Encode(certPEM, certBlock)
-			if err != nil {
-				return fmt.Errorf("Could not encode cert as PEM data: %s", err)
-			}
+	if c.Readiness.Enabled && c.Readiness.Port == 0 {
+		return errors.New("readiness.port is required when the readiness endpoint is enabled")
+	}
+
+	if c.RuntimeAPI.Enabled && c.RuntimeAPI.ServerSlots <= 0 {
+		c.RuntimeAPI.ServerSlots = ServerSlotsDefault
+	} else if !c.RuntimeAPI.Enabled {
+		c.RuntimeAPI.ServerSlots = 0
+	}
+
+	if c.LoadBalancing.Algorithm != "" {
+		if err := validateBalanceAlgorithm(c.LoadBalancing.Algorithm); err != nil {
+			return fmt.Errorf("load_balancing.algorithm: %s", err)
+		}
+	}
+	for port, algorithm := range c.LoadBalancing.Ports {
+		if err := validateBalanceAlgorithm(algorithm); err != nil {
+			return fmt.Errorf("load_balancing.ports[%d]: %s", port, err)
+		}
+	}
+
+	if c.ProxyProtocol.Send != "" {
+		if err := validateProxyProtocolVersion(c.ProxyProtocol.Send); err != nil {
+			return fmt.Errorf("proxy_protocol.send: %s", err)
+		}
+	}
+	for port, version := range c.ProxyProtocol.Ports {
+		if err := validateProxyProtocolVersion(version); err != nil {
+			return fmt.Errorf("proxy_protocol.ports[%d]: %s", port, err)
+		}
+	}
+	for i, route := range c.ProxyProtocol.Routes {
+		if route.Port == 0 {
+			return fmt.Errorf("proxy_protocol.routes[%d].port is required", i)
+		}
+		if err := validateProxyProtocolVersion(route.Version); err != nil {
+			return fmt.Errorf("proxy_protocol.routes[%d]: %s", i, err)
+		}
+	}
+
+	if err := c.Timeouts.validate(); err != nil {
+		return err
+	}
+
+	if err := c.AccessControl.validate(); err != nil {
+		return err
+	}
+
+	if err := c.RateLimit.validate(); err != nil {
+		return err
+	}
+
+	if len(c.TLSTermination.Ports) > 0 {
+		if c.TLSTermination.CertDir == "" {
+			return errors.New("tls_termination.cert_dir is required when tls_termination.ports are set")
+		}
+		info, err := os.Stat(c.TLSTermination.CertDir)
+		if err != nil {
+			return fmt.Errorf("tls_termination.cert_dir: %s", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("tls_termination.cert_dir %q is not a directory", c.TLSTermination.CertDir)
+		}
+	}
+
+	if c.HealthCheck.Enabled {
+		if c.HealthCheck.Interval <= 0 {
+			c.HealthCheck.Interval = HealthCheckIntervalDefault
+		}
+		if c.HealthCheck.Rise <= 0 {
+			c.HealthCheck.Rise = HealthCheckRiseDefault
+		}
+		if c.HealthCheck.Fall <= 0 {
+			c.HealthCheck.Fall = HealthCheckFallDefault
+		}
+	}
+
+	if c.BackendTLS.Enabled {
+		if err := c.BackendTLS.Validate(); err != nil {
+			return err
+		}
+	} else {
+		c.BackendTLS.CACertificatePath = ""
+		c.BackendTLS.ClientCertAndKeyPath = ""
+	}
 
-			pemData = []byte(strings.TrimSpace(string(pemData)))
-			var keyBlock *pem.Block
-			keyBlock, pemData = pem.Decode(pemData)
-			if keyBlock == nil {
-				return fmt.Errorf("Invalid PEM PRIVATE KEY found in file %q", c.BackendTLS.ClientCertAndKeyPath)
+	return nil
+}
+
+// Validate checks that the CA certificates and the client certificate and key
+// configured for backend TLS can be read and parsed. It is meant to be called
+// when backend TLS is enabled.
+func (b BackendTLSConfig) Validate() error {
+	if b.CACertificatePath != "" {
+		pemData, err := os.ReadFile(b.CACertificatePath)
+		if err != nil {
+			return err
+		}
+
+		pemData = []byte(strings.TrimSpace(string(pemData)))
+		if len(pemData) > 0 {
+			var block *pem.Block
+			block, _ = pem.Decode(pemData)
+			if block == nil {
+				return fmt.Errorf("Invalid PEM block found in file %q", b.CACertificatePath)
 			}
-			keyPEM := bytes.NewBuffer([]byte{})
-			err = pem.Encode(keyPEM, keyBlock)
-			if err != nil {
-				return fmt.Errorf("Could not encode key as PEM data: %s", err)
+			if len(block.Headers) != 0 {
+				return fmt.Errorf("Unexpected headers in PEM block in file %q: %v", b.CACertificatePath, block.Headers)
 			}
-
-			if len(pemData) > 0 {
-				return fmt.Errorf("Unexpected data at the end of %s", c.BackendTLS.ClientCertAndKeyPath)
+			if block.Type != "CERTIFICATE" {
+				return fmt.Errorf("Unexpected PEM block type %q in file %q (wanted CERTIFICATE)", block.Type, b.CACertificatePath)
 			}
-
-			_, err = tls.X509KeyPair(certPEM.Bytes(), keyPEM.Bytes())
+			_, err = x509.ParseCertificate(block.Bytes)
 			if err != nil {
-				return fmt.Errorf("Unable to validate backend TLS client cert + key in file %q: %s", c.BackendTLS.ClientCertAndKeyPath, err)
+				return fmt.Errorf("failed to parse certificate in %q: %s", b.CACertificatePath, err)
 			}
 		}
 	} else {
-		c.BackendTLS.CACertificatePath = ""
-		c.BackendTLS.ClientCertAndKeyPath = ""
+		return fmt.Errorf("Backend TLS was enabled but no CA certificates were specified")
 	}
 
+	if b.ClientCertAndKeyPath != "" {
+		pemData, err := os.ReadFile(b.ClientCertAndKeyPath)
+		if err != nil {
+			return err
+		}
+
+		pemData = []byte(strings.TrimSpace(string(pemData)))
+		var certBlock *pem.Block
+		certBlock, pemData = pem.Decode(pemData)
+		if certBlock == nil {
+			return fmt.Errorf("Invalid PEM CERTIFICATE found in file %q", b.ClientCertAndKeyPath)
+		}
+		certPEM := bytes.NewBuffer([]byte{})
+		err = pem.Encode(certPEM, certBlock)
+		if err != nil {
+			return fmt.Errorf("Could not encode cert as PEM data: %s", err)
+		}
+
+		pemData = []byte(strings.TrimSpace(string(pemData)))
+		var keyBlock *pem.Block
+		keyBlock, pemData = pem.Decode(pemData)
+		if keyBlock == nil {
+			return fmt.Errorf("Invalid PEM PRIVATE KEY found in file %q", b.ClientCertAndKeyPath)
+		}
+		keyPEM := bytes.NewBuffer([]byte{})
+		err = pem.Encode(keyPEM, keyBlock)
+		if err != nil {
+			return fmt.Errorf("Could not encode key as PEM data: %s", err)
+		}
+
+		if len(pemData) > 0 {
+			return fmt.Errorf("Unexpected data at the end of %s", b.ClientCertAndKeyPath)
+		}
+
+		_, err = tls.X509KeyPair(certPEM.Bytes(), keyPEM.Bytes())
+		if err != nil {
+			return fmt.Errorf("Unable to validate backend TLS client cert + key in file %q: %s", b.ClientCertAndKeyPath, err)
+		}
+	}
 	return nil
 }
+
+// CertificateExpiry returns when the first of the CA certificates and the
+// client certificate expire. A zero time is returned for a path that is not
+// configured.
+func (b BackendTLSConfig) CertificateExpiry() (caNotAfter time.Time, clientNotAfter time.Time, err error) {
+	if b.CACertificatePath != "" {
+		caNotAfter, err = earliestNotAfter(b.CACertificatePath)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	if b.ClientCertAndKeyPath != "" {
+		clientNotAfter, err = earliestNotAfter(b.ClientCertAndKeyPath)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	return caNotAfter, clientNotAfter, nil
+}
+
+func earliestNotAfter(path string) (time.Time, error) {
+	pemData, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var notAfter time.Time
+	for {
+		var block *pem.Block
+		block, pemData = pem.Decode(pemData)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse certificate in %q: %s", path, err)
+		}
+		if notAfter.IsZero() || cert.NotAfter.Before(notAfter) {
+			notAfter = cert.NotAfter
+		}
+	}
+	return notAfter, nil
+}
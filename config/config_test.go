@@ -85,6 +85,7 @@ var _ = Describe("Config", Serial, func() {
 				},
 				HaProxyPidFile:               "/path/to/pid/file",
 				IsolationSegments:            []string{"foo-iso-seg"},
+				RouterGroups:                 []string{"default-tcp"},
 				ReservedSystemComponentPorts: []uint16{8080, 8081},
 				BackendTLS: config.BackendTLSConfig{
 					Enabled:              true,
@@ -200,6 +201,339 @@ var _ = Describe("Config", Serial, func() {
 			Expect(*cfg).To(Equal(expectedCfg))
 		})
 	})
+	Context("when the runtime api is enabled", func() {
+		It("loads the number of server slots", func() {
+			cfg, err := config.New("fixtures/runtime_api.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.RuntimeAPI).To(Equal(config.RuntimeAPIConfig{
+				Enabled:     true,
+				ServerSlots: 20,
+			}))
+		})
+
+		Context("when server_slots is not set", func() {
+			It("defaults to 10", func() {
+				cfg, err := config.New("fixtures/runtime_api_default_slots.yml")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.RuntimeAPI.ServerSlots).To(Equal(config.ServerSlotsDefault))
+			})
+		})
+	})
+
+	Context("when the runtime api is disabled", func() {
+		It("does not allocate server slots", func() {
+			cfg, err := config.New("fixtures/runtime_api_disabled.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.RuntimeAPI).To(Equal(config.RuntimeAPIConfig{}))
+		})
+	})
+
+	Context("when frontend_ip_family is set", func() {
+		It("loads the address family", func() {
+			cfg, err := config.New("fixtures/frontend_ip_family_dual_stack.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.FrontendIPFamily).To(Equal(config.FrontendDualStack))
+		})
+
+		Context("when it is not a supported address family", func() {
+			It("returns an error", func() {
+				_, err := config.New("fixtures/frontend_ip_family_invalid.yml")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("frontend_ip_family"))
+			})
+		})
+	})
+
+	Context("when the admin api is enabled", func() {
+		It("loads the admin api config", func() {
+			cfg, err := config.New("fixtures/admin_api.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.AdminAPI).To(Equal(config.AdminAPIConfig{
+				Enabled:          true,
+				Port:             8444,
+				CertPath:         "/a/admin_cert",
+				KeyPath:          "/b/admin_key",
+				ClientCACertPath: "/c/admin_client_ca",
+			}))
+		})
+
+		Context("when the certificates are missing", func() {
+			It("returns an error", func() {
+				_, err := config.New("fixtures/admin_api_missing_certs.yml")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("admin_api.cert_path"))
+			})
+		})
+	})
+
+	Context("when the readiness endpoint is enabled", func() {
+		It("loads the readiness config", func() {
+			cfg, err := config.New("fixtures/readiness.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Readiness).To(Equal(config.ReadinessConfig{
+				Enabled: true,
+				Port:    8445,
+			}))
+		})
+
+		Context("when the port is missing", func() {
+			It("returns an error", func() {
+				_, err := config.New("fixtures/readiness_missing_port.yml")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("readiness.port"))
+			})
+		})
+	})
+
+	Context("when the prometheus metrics emitter is selected", func() {
+		It("loads the metrics config", func() {
+			cfg, err := config.New("fixtures/prometheus_metrics.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Metrics).To(Equal(config.MetricsConfig{
+				Emitter:        config.PrometheusMetricsEmitter,
+				PrometheusPort: 9108,
+			}))
+		})
+
+		Context("when the port is missing", func() {
+			It("returns an error", func() {
+				_, err := config.New("fixtures/prometheus_metrics_missing_port.yml")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("metrics.prometheus_port"))
+			})
+		})
+	})
+
+	Context("when health checks are enabled", func() {
+		It("loads the health check config", func() {
+			cfg, err := config.New("fixtures/health_check.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.HealthCheck).To(Equal(config.HealthCheckConfig{
+				Enabled:      true,
+				Interval:     5 * time.Second,
+				Rise:         1,
+				Fall:         5,
+				TLSHandshake: true,
+			}))
+		})
+
+		Context("when the thresholds are not set", func() {
+			It("uses the defaults", func() {
+				cfg, err := config.New("fixtures/health_check_defaults.yml")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.HealthCheck).To(Equal(config.HealthCheckConfig{
+					Enabled:  true,
+					Interval: config.HealthCheckIntervalDefault,
+					Rise:     config.HealthCheckRiseDefault,
+					Fall:     config.HealthCheckFallDefault,
+				}))
+			})
+		})
+	})
+
+	Context("when load balancing is configured", func() {
+		It("loads the default and per port algorithms", func() {
+			cfg, err := config.New("fixtures/load_balancing.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.LoadBalancing).To(Equal(config.LoadBalancingConfig{
+				Algorithm: config.BalanceLeastConn,
+				Ports: map[uint16]config.BalanceAlgorithm{
+					5432: config.BalanceSource,
+					1883: config.BalanceFirst,
+				},
+			}))
+			Expect(cfg.LoadBalancing.AlgorithmForPort(5432)).To(Equal(config.BalanceSource))
+			Expect(cfg.LoadBalancing.AlgorithmForPort(8080)).To(Equal(config.BalanceLeastConn))
+		})
+
+		Context("when an algorithm is not supported", func() {
+			It("returns an error", func() {
+				_, err := config.New("fixtures/load_balancing_invalid.yml")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("load_balancing.ports[5432]"))
+				Expect(err.Error()).To(ContainSubstring("random-ish"))
+			})
+		})
+	})
+
+	Context("when the proxy protocol is configured", func() {
+		var cfg *config.Config
+
+		BeforeEach(func() {
+			var err error
+			cfg, err = config.New("fixtures/proxy_protocol.yml")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("loads the proxy protocol config", func() {
+			Expect(cfg.ProxyProtocol).To(Equal(config.ProxyProtocolConfig{
+				Accept: true,
+				Send:   config.ProxyProtocolV2,
+				Ports:  map[uint16]config.ProxyProtocolVersion{5432: config.ProxyProtocolNone},
+				Routes: []config.ProxyProtocolRoute{
+					{Port: 443, SniHostname: "legacy.example.com", Version: config.ProxyProtocolV1},
+				},
+			}))
+		})
+
+		It("picks the most specific version for a route", func() {
+			Expect(cfg.ProxyProtocol.VersionForRoute(443, "legacy.example.com")).To(Equal(config.ProxyProtocolV1))
+			Expect(cfg.ProxyProtocol.VersionForRoute(443, "")).To(Equal(config.ProxyProtocolV2))
+			Expect(cfg.ProxyProtocol.VersionForRoute(5432, "")).To(BeEmpty())
+		})
+
+		Context("when a version is not supported", func() {
+			It("returns an error", func() {
+				_, err := config.New("fixtures/proxy_protocol_invalid.yml")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("proxy_protocol.send"))
+			})
+		})
+	})
+
+	Context("when timeout profiles are configured", func() {
+		It("loads the profiles and their port ranges", func() {
+			cfg, err := config.New("fixtures/timeouts.yml")
+			Expect(err).NotTo(HaveOccurred())
+
+			database := config.TimeoutProfile{
+				Connect:         5 * time.Second,
+				Client:          time.Hour,
+				Server:          time.Hour,
+				Tunnel:          2 * time.Hour,
+				ClientFin:       30 * time.Second,
+				FrontendMaxConn: 1000,
+				ServerMaxConn:   100,
+			}
+			Expect(cfg.Timeouts).To(Equal(config.TimeoutsConfig{
+				Profiles: map[string]config.TimeoutProfile{"database": database},
+				Ports: []config.PortRangeProfile{
+					{PortRange: config.PortRange{Start: 5000, End: 5999}, Profile: "database"},
+					{PortRange: config.PortRange{Start: 6379, End: 6379}, Profile: "database"},
+				},
+			}))
+
+			profile, ok := cfg.Timeouts.ProfileForPort(6379)
+			Expect(ok).To(BeTrue())
+			Expect(profile).To(Equal(database))
+			_, ok = cfg.Timeouts.ProfileForPort(6000)
+			Expect(ok).To(BeFalse())
+		})
+
+		Context("when a port range uses an unknown profile", func() {
+			It("returns an error", func() {
+				_, err := config.New("fixtures/timeouts_unknown_profile.yml")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(`unknown profile "missing"`))
+			})
+		})
+	})
+
+	Context("when tls termination is configured", func() {
+		It("loads the ports to terminate tls on", func() {
+			cfg, err := config.New("fixtures/tls_termination.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.TLSTermination).To(Equal(config.TLSTerminationConfig{
+				CertDir: "fixtures",
+				Ports:   []uint16{443, 8443},
+			}))
+			Expect(cfg.TLSTermination.Terminates(8443)).To(BeTrue())
+			Expect(cfg.TLSTermination.Terminates(80)).To(BeFalse())
+		})
+
+		Context("when the certificate directory does not exist", func() {
+			It("returns an error", func() {
+				_, err := config.New("fixtures/tls_termination_missing_cert_dir.yml")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("tls_termination.cert_dir"))
+			})
+		})
+	})
+
+	Context("when access control is configured", func() {
+		It("loads the rules for each port range", func() {
+			cfg, err := config.New("fixtures/access_control.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.AccessControl).To(Equal(config.AccessControlConfig{
+				Ports: []config.AccessRule{
+					{
+						PortRange: config.PortRange{Start: 5000, End: 5999},
+						Allow:     []string{"10.0.0.0/8", "fd00::/8"},
+						Deny:      []string{"10.1.0.0/16"},
+					},
+					{
+						PortRange: config.PortRange{Start: 6379, End: 6379},
+						Allow:     []string{"192.168.1.10"},
+					},
+				},
+			}))
+
+			rule, ok := cfg.AccessControl.RuleForPort(5432)
+			Expect(ok).To(BeTrue())
+			Expect(rule.Deny).To(ConsistOf("10.1.0.0/16"))
+			_, ok = cfg.AccessControl.RuleForPort(80)
+			Expect(ok).To(BeFalse())
+		})
+
+		Context("when a source is not a CIDR or IP address", func() {
+			It("returns an error", func() {
+				_, err := config.New("fixtures/access_control_invalid_cidr.yml")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(`access_control.ports[0]: "10.0.0.0/33"`))
+			})
+		})
+	})
+
+	Context("when rate limits are configured", func() {
+		It("loads the limits for each port range", func() {
+			cfg, err := config.New("fixtures/rate_limit.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.RateLimit).To(Equal(config.RateLimitConfig{
+				TableSize: config.RateLimitTableSizeDefault,
+				Ports: []config.RateLimitRule{
+					{
+						PortRange:             config.PortRange{Start: 5000, End: 5999},
+						ConnectionRate:        20,
+						RatePeriod:            30 * time.Second,
+						ConcurrentConnections: 10,
+					},
+					{
+						PortRange:             config.PortRange{Start: 6379, End: 6379},
+						RatePeriod:            config.RateLimitPeriodDefault,
+						ConcurrentConnections: 50,
+					},
+				},
+			}))
+		})
+	})
+
+	Describe("BackendTLSConfig.CertificateExpiry", func() {
+		It("returns when the CA and client certificates expire", func() {
+			backendTLS := config.BackendTLSConfig{Enabled: true, CACertificatePath: caFile, ClientCertAndKeyPath: certAndKeyFile}
+			Expect(backendTLS.Validate()).To(Succeed())
+
+			caNotAfter, clientNotAfter, err := backendTLS.CertificateExpiry()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(caNotAfter).To(BeTemporally(">", time.Now()))
+			Expect(clientNotAfter).To(BeTemporally(">", time.Now()))
+		})
+
+		It("returns zero times for paths that are not configured", func() {
+			caNotAfter, clientNotAfter, err := config.BackendTLSConfig{}.CertificateExpiry()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(caNotAfter).To(BeZero())
+			Expect(clientNotAfter).To(BeZero())
+		})
+	})
+
+	Context("when the metrics emitter is not supported", func() {
+		It("returns an error", func() {
+			_, err := config.New("fixtures/invalid_metrics_emitter.yml")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("metrics.emitter"))
+		})
+	})
+
 	Context("when drain_wait is a negative number", func() {
 		It("defaults to 20s", func() {
 			cfg, err := config.New("fixtures/negative_drain_wait.yml")
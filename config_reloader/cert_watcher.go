@@ -0,0 +1,197 @@
+package config_reloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/cf-tcp-router/config"
+	"code.cloudfoundry.org/cf-tcp-router/metrics_reporter"
+	"code.cloudfoundry.org/cf-tcp-router/routing_table"
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+var (
+	caCertificateNotAfter          = metrics_reporter.Value("BackendTLSCACertificateNotAfter")
+	clientCertificateNotAfter      = metrics_reporter.Value("BackendTLSClientCertificateNotAfter")
+	rejectedBackendTLSCertificates = metrics_reporter.Counter("RejectedBackendTLSCertificates")
+)
+
+// CertWatcher polls the backend TLS certificate files and reconfigures the tcp
+// load balancer when their content changes, so that rotated certificates are
+// picked up without waiting for a routing change. It also reports when the
+// certificates in use expire.
+//
+// CertWatcher is a BackendTLSSetter itself, so that it keeps watching the
+// right files when the config is reloaded.
+type CertWatcher struct {
+	logger           lager.Logger
+	clock            clock.Clock
+	interval         time.Duration
+	backendTLSSetter BackendTLSSetter
+	updater          routing_table.Updater
+
+	lock           *sync.Mutex
+	backendTlsCfg  config.BackendTLSConfig
+	fingerprint    string
+	caNotAfter     time.Time
+	clientNotAfter time.Time
+}
+
+func NewCertWatcher(
+	logger lager.Logger,
+	clock clock.Clock,
+	interval time.Duration,
+	backendTlsCfg config.BackendTLSConfig,
+	backendTLSSetter BackendTLSSetter,
+	updater routing_table.Updater,
+) *CertWatcher {
+	return &CertWatcher{
+		logger:           logger.Session("cert-watcher"),
+		clock:            clock,
+		interval:         interval,
+		backendTLSSetter: backendTLSSetter,
+		updater:          updater,
+		lock:             new(sync.Mutex),
+		backendTlsCfg:    backendTlsCfg,
+	}
+}
+
+func (w *CertWatcher) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	w.lock.Lock()
+	w.applied(w.backendTlsCfg)
+	w.lock.Unlock()
+	w.emitExpiry()
+
+	close(ready)
+	w.logger.Info("started")
+
+	ticker := w.clock.NewTicker(w.interval)
+	for {
+		select {
+		case <-ticker.C():
+			w.Check()
+		case sig := <-signals:
+			if sig != syscall.SIGUSR2 {
+				w.logger.Info("stopping")
+				ticker.Stop()
+				return nil
+			}
+		}
+	}
+}
+
+func (w *CertWatcher) SetBackendTLS(backendTlsCfg config.BackendTLSConfig) error {
+	err := w.backendTLSSetter.SetBackendTLS(backendTlsCfg)
+	if err != nil {
+		return err
+	}
+
+	w.lock.Lock()
+	w.applied(backendTlsCfg)
+	w.lock.Unlock()
+	w.emitExpiry()
+	return nil
+}
+
+// applied records the certificates in use. Callers must hold the lock.
+func (w *CertWatcher) applied(backendTlsCfg config.BackendTLSConfig) {
+	w.backendTlsCfg = backendTlsCfg
+	w.fingerprint = w.currentFingerprint(backendTlsCfg)
+	w.caNotAfter, w.clientNotAfter = time.Time{}, time.Time{}
+	if !backendTlsCfg.Enabled {
+		return
+	}
+
+	var err error
+	w.caNotAfter, w.clientNotAfter, err = backendTlsCfg.CertificateExpiry()
+	if err != nil {
+		w.logger.Error("failed-to-read-certificate-expiry", err)
+	}
+}
+
+// Check reconfigures the tcp load balancer when the certificate files have
+// changed since they were last applied. Certificates that fail validation are
+// not applied, and are not checked again until they change once more. The tcp
+// load balancer keeps using its own copy of the last valid certificates, so
+// that later reloads do not pick up the rejected files either.
+func (w *CertWatcher) Check() {
+	w.lock.Lock()
+	backendTlsCfg := w.backendTlsCfg
+	lastFingerprint := w.fingerprint
+	w.lock.Unlock()
+
+	if !backendTlsCfg.Enabled {
+		return
+	}
+
+	fingerprint := w.currentFingerprint(backendTlsCfg)
+	if fingerprint == lastFingerprint {
+		w.emitExpiry()
+		return
+	}
+
+	logger := w.logger.Session("check", lager.Data{"ca-cert-path": backendTlsCfg.CACertificatePath, "client-cert-and-key-path": backendTlsCfg.ClientCertAndKeyPath})
+	logger.Info("certificates-changed")
+
+	err := backendTlsCfg.Validate()
+	if err != nil {
+		logger.Error("rejected-certificates", err)
+		rejectedBackendTLSCertificates.Add(1)
+		w.lock.Lock()
+		w.fingerprint = fingerprint
+		w.lock.Unlock()
+		return
+	}
+
+	err = w.SetBackendTLS(backendTlsCfg)
+	if err != nil {
+		logger.Error("failed-to-apply-certificates", err)
+		return
+	}
+
+	err = w.updater.Reconfigure()
+	if err != nil {
+		logger.Error("failed-to-reconfigure", err)
+		return
+	}
+	logger.Info("applied-certificates")
+}
+
+// currentFingerprint hashes the content of the certificate files. Files that
+// cannot be read are hashed as empty, so that they are picked up once they
+// appear and then fail validation.
+func (w *CertWatcher) currentFingerprint(backendTlsCfg config.BackendTLSConfig) string {
+	hash := sha256.New()
+	for _, path := range []string{backendTlsCfg.CACertificatePath, backendTlsCfg.ClientCertAndKeyPath} {
+		if path == "" {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			w.logger.Debug("failed-to-read-certificate", lager.Data{"path": path, "error": err.Error()})
+		}
+		hash.Write(content)
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (w *CertWatcher) emitExpiry() {
+	w.lock.Lock()
+	caNotAfter, clientNotAfter := w.caNotAfter, w.clientNotAfter
+	w.lock.Unlock()
+
+	if !caNotAfter.IsZero() {
+		// #nosec G115 - certificates expiring before 1970 are not valid anyway
+		caCertificateNotAfter.Send(uint64(caNotAfter.Unix()))
+	}
+	if !clientNotAfter.IsZero() {
+		// #nosec G115 - certificates expiring before 1970 are not valid anyway
+		clientCertificateNotAfter.Send(uint64(clientNotAfter.Unix()))
+	}
+}
@@ -0,0 +1,173 @@
+package config_reloader_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	tlshelpers "code.cloudfoundry.org/cf-routing-test-helpers/tls"
+	"code.cloudfoundry.org/cf-tcp-router/config"
+	"code.cloudfoundry.org/cf-tcp-router/config_reloader"
+	"code.cloudfoundry.org/cf-tcp-router/config_reloader/fakes"
+	routingtablefakes "code.cloudfoundry.org/cf-tcp-router/routing_table/fakes"
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"github.com/cloudfoundry/dropsonde/metric_sender/fake"
+	"github.com/cloudfoundry/dropsonde/metrics"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("CertWatcher", func() {
+	const interval = 10 * time.Second
+
+	var (
+		logger               *lagertest.TestLogger
+		fakeClock            *fakeclock.FakeClock
+		fakeBackendTLSSetter *fakes.FakeBackendTLSSetter
+		fakeUpdater          *routingtablefakes.FakeUpdater
+		sender               *fake.FakeMetricSender
+		backendTlsCfg        config.BackendTLSConfig
+		certWatcher          *config_reloader.CertWatcher
+		process              ifrit.Process
+	)
+
+	generateCA := func() string {
+		caFile, _ := tlshelpers.GenerateCa()
+		DeferCleanup(os.Remove, caFile)
+		return caFile
+	}
+
+	replaceFile := func(path string, sourcePath string) {
+		content, err := os.ReadFile(sourcePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(path, content, 0600)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		fakeBackendTLSSetter = new(fakes.FakeBackendTLSSetter)
+		fakeUpdater = new(routingtablefakes.FakeUpdater)
+		sender = fake.NewFakeMetricSender()
+		metrics.Initialize(sender, nil)
+		backendTlsCfg = config.BackendTLSConfig{Enabled: true, CACertificatePath: generateCA()}
+	})
+
+	JustBeforeEach(func() {
+		certWatcher = config_reloader.NewCertWatcher(logger, fakeClock, interval, backendTlsCfg, fakeBackendTLSSetter, fakeUpdater)
+		process = ifrit.Invoke(certWatcher)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive(BeNil()))
+	})
+
+	It("emits when the CA certificate expires", func() {
+		caNotAfter, _, err := backendTlsCfg.CertificateExpiry()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sender.GetValue("BackendTLSCACertificateNotAfter").Value).To(Equal(float64(caNotAfter.Unix())))
+	})
+
+	Context("when the certificates do not change", func() {
+		It("does not reconfigure", func() {
+			fakeClock.WaitForWatcherAndIncrement(interval)
+			Consistently(fakeUpdater.ReconfigureCallCount).Should(Equal(0))
+			Expect(fakeBackendTLSSetter.SetBackendTLSCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the CA certificate is rotated", func() {
+		JustBeforeEach(func() {
+			replaceFile(backendTlsCfg.CACertificatePath, generateCA())
+		})
+
+		It("applies the new certificate and reconfigures on the next check", func() {
+			fakeClock.WaitForWatcherAndIncrement(interval)
+			Eventually(fakeUpdater.ReconfigureCallCount).Should(Equal(1))
+
+			Expect(fakeBackendTLSSetter.SetBackendTLSCallCount()).To(Equal(1))
+			Expect(fakeBackendTLSSetter.SetBackendTLSArgsForCall(0)).To(Equal(backendTlsCfg))
+		})
+
+		It("only reconfigures once per change", func() {
+			certWatcher.Check()
+			certWatcher.Check()
+			Expect(fakeUpdater.ReconfigureCallCount()).To(Equal(1))
+		})
+
+		Context("when the configurer rejects the certificate", func() {
+			BeforeEach(func() {
+				fakeBackendTLSSetter.SetBackendTLSReturns(errors.New("CA file not found"))
+			})
+
+			It("does not reconfigure", func() {
+				certWatcher.Check()
+				Expect(logger).To(gbytes.Say("failed-to-apply-certificates"))
+				Expect(fakeUpdater.ReconfigureCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Context("when the CA certificate is replaced with an invalid one", func() {
+		JustBeforeEach(func() {
+			replaceFile(backendTlsCfg.CACertificatePath, "../config/fixtures/bad_ca.pem")
+		})
+
+		It("refuses to apply it", func() {
+			certWatcher.Check()
+			Expect(logger).To(gbytes.Say("rejected-certificates"))
+			Expect(fakeBackendTLSSetter.SetBackendTLSCallCount()).To(Equal(0))
+			Expect(fakeUpdater.ReconfigureCallCount()).To(Equal(0))
+			Expect(sender.GetCounter("RejectedBackendTLSCertificates")).To(Equal(uint64(1)))
+		})
+
+		It("does not reject it again until it changes", func() {
+			certWatcher.Check()
+			certWatcher.Check()
+			Expect(sender.GetCounter("RejectedBackendTLSCertificates")).To(Equal(uint64(1)))
+
+			replaceFile(backendTlsCfg.CACertificatePath, generateCA())
+			certWatcher.Check()
+			Expect(fakeUpdater.ReconfigureCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when backend tls is disabled", func() {
+		BeforeEach(func() {
+			backendTlsCfg = config.BackendTLSConfig{}
+		})
+
+		It("does not check any files", func() {
+			certWatcher.Check()
+			Expect(fakeUpdater.ReconfigureCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("SetBackendTLS", func() {
+		It("applies the settings and watches the new files", func() {
+			newBackendTlsCfg := config.BackendTLSConfig{Enabled: true, CACertificatePath: generateCA()}
+			Expect(certWatcher.SetBackendTLS(newBackendTlsCfg)).To(Succeed())
+			Expect(fakeBackendTLSSetter.SetBackendTLSArgsForCall(0)).To(Equal(newBackendTlsCfg))
+
+			replaceFile(newBackendTlsCfg.CACertificatePath, generateCA())
+			certWatcher.Check()
+			Expect(fakeBackendTLSSetter.SetBackendTLSCallCount()).To(Equal(2))
+			Expect(fakeBackendTLSSetter.SetBackendTLSArgsForCall(1)).To(Equal(newBackendTlsCfg))
+		})
+
+		It("keeps watching the old files when the settings are rejected", func() {
+			fakeBackendTLSSetter.SetBackendTLSReturns(errors.New("CA file not found"))
+			Expect(certWatcher.SetBackendTLS(config.BackendTLSConfig{Enabled: true, CACertificatePath: "file/path/does/not/exist"})).NotTo(Succeed())
+
+			fakeBackendTLSSetter.SetBackendTLSReturns(nil)
+			replaceFile(backendTlsCfg.CACertificatePath, generateCA())
+			certWatcher.Check()
+			Expect(fakeBackendTLSSetter.SetBackendTLSArgsForCall(1)).To(Equal(backendTlsCfg))
+		})
+	})
+})
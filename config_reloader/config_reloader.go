@@ -0,0 +1,158 @@
+package config_reloader
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"syscall"
+
+	"code.cloudfoundry.org/cf-tcp-router/config"
+	"code.cloudfoundry.org/cf-tcp-router/metrics_reporter"
+	"code.cloudfoundry.org/cf-tcp-router/routing_table"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+var (
+	configReloads       = metrics_reporter.Counter("ConfigReloads")
+	failedConfigReloads = metrics_reporter.Counter("FailedConfigReloads")
+)
+
+// reloadableFields are the yaml keys of the config whose changes are applied
+// without restarting the tcp router.
+var reloadableFields = map[string]bool{
+	"backend_tls":                     true,
+	"drain_wait":                      true,
+	"reserved_system_component_ports": true,
+}
+
+//go:generate counterfeiter -o fakes/fake_backend_tls_setter.go . BackendTLSSetter
+type BackendTLSSetter interface {
+	SetBackendTLS(backendTlsCfg config.BackendTLSConfig) error
+}
+
+//go:generate counterfeiter -o fakes/fake_port_checker.go . PortChecker
+type PortChecker interface {
+	Check(systemComponentPorts []uint16) (bool, error)
+}
+
+// ConfigReloader re-reads the tcp router config file whenever a signal is
+// received on its reload channel, typically SIGHUP.
+type ConfigReloader struct {
+	logger           lager.Logger
+	configFilePath   string
+	cfg              config.Config
+	reload           <-chan os.Signal
+	backendTLSSetter BackendTLSSetter
+	updater          routing_table.Updater
+	portChecker      PortChecker
+}
+
+func New(
+	logger lager.Logger,
+	configFilePath string,
+	cfg config.Config,
+	reload <-chan os.Signal,
+	backendTLSSetter BackendTLSSetter,
+	updater routing_table.Updater,
+	portChecker PortChecker,
+) *ConfigReloader {
+	return &ConfigReloader{
+		logger:           logger.Session("config-reloader"),
+		configFilePath:   configFilePath,
+		cfg:              cfg,
+		reload:           reload,
+		backendTLSSetter: backendTLSSetter,
+		updater:          updater,
+		portChecker:      portChecker,
+	}
+}
+
+func (r *ConfigReloader) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	close(ready)
+	r.logger.Info("started")
+
+	for {
+		select {
+		case <-r.reload:
+			r.Reload()
+		case sig := <-signals:
+			if sig != syscall.SIGUSR2 {
+				r.logger.Info("stopping")
+				return nil
+			}
+		}
+	}
+}
+
+// Reload applies the changes to backend_tls, drain_wait and
+// reserved_system_component_ports and reconfigures the tcp load balancer once.
+// Changes to any other setting are logged and take effect after a restart.
+// Nothing is applied when the new config is invalid.
+func (r *ConfigReloader) Reload() {
+	logger := r.logger.Session("reload", lager.Data{"config-file": r.configFilePath})
+	logger.Info("starting")
+	defer logger.Info("finished")
+
+	newCfg, err := config.New(r.configFilePath)
+	if err != nil {
+		logger.Error("failed-to-load-config", err)
+		failedConfigReloads.Add(1)
+		return
+	}
+
+	if restartRequired := changedFields(r.cfg, *newCfg, false); len(restartRequired) > 0 {
+		logger.Info("changes-require-restart", lager.Data{"fields": restartRequired})
+	}
+
+	applied := changedFields(r.cfg, *newCfg, true)
+	if !reflect.DeepEqual(r.cfg.BackendTLS, newCfg.BackendTLS) {
+		err = r.backendTLSSetter.SetBackendTLS(newCfg.BackendTLS)
+		if err != nil {
+			logger.Error("failed-to-apply-backend-tls", err)
+			failedConfigReloads.Add(1)
+			return
+		}
+		r.cfg.BackendTLS = newCfg.BackendTLS
+	}
+
+	if r.cfg.DrainWaitDuration != newCfg.DrainWaitDuration {
+		r.updater.SetDrainWaitDuration(newCfg.DrainWaitDuration)
+		r.cfg.DrainWaitDuration = newCfg.DrainWaitDuration
+	}
+
+	if !reflect.DeepEqual(r.cfg.ReservedSystemComponentPorts, newCfg.ReservedSystemComponentPorts) {
+		r.cfg.ReservedSystemComponentPorts = newCfg.ReservedSystemComponentPorts
+		_, err = r.portChecker.Check(newCfg.ReservedSystemComponentPorts)
+		if err != nil {
+			logger.Error("router-group-port-checker-error", err)
+		}
+	}
+
+	logger.Info("applied-changes", lager.Data{"fields": applied})
+	configReloads.Add(1)
+
+	err = r.updater.Reconfigure()
+	if err != nil {
+		logger.Error("failed-to-reconfigure", err)
+	}
+}
+
+// changedFields returns the yaml keys of the top-level settings that differ
+// between the two configs, limited to either the reloadable ones or the ones
+// that need a restart.
+func changedFields(current, next config.Config, reloadable bool) []string {
+	var fields []string
+	currentValue := reflect.ValueOf(current)
+	nextValue := reflect.ValueOf(next)
+	configType := currentValue.Type()
+	for i := 0; i < configType.NumField(); i++ {
+		name := strings.Split(configType.Field(i).Tag.Get("yaml"), ",")[0]
+		if reloadableFields[name] != reloadable {
+			continue
+		}
+		if !reflect.DeepEqual(currentValue.Field(i).Interface(), nextValue.Field(i).Interface()) {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,179 @@
+package config_reloader_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	tlshelpers "code.cloudfoundry.org/cf-routing-test-helpers/tls"
+	"code.cloudfoundry.org/cf-tcp-router/config"
+	"code.cloudfoundry.org/cf-tcp-router/config_reloader"
+	"code.cloudfoundry.org/cf-tcp-router/config_reloader/fakes"
+	routingtablefakes "code.cloudfoundry.org/cf-tcp-router/routing_table/fakes"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"github.com/cloudfoundry/dropsonde/metric_sender/fake"
+	"github.com/cloudfoundry/dropsonde/metrics"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("ConfigReloader", func() {
+	var (
+		logger               *lagertest.TestLogger
+		configFilePath       string
+		caFile               string
+		cfg                  config.Config
+		reload               chan os.Signal
+		fakeBackendTLSSetter *fakes.FakeBackendTLSSetter
+		fakeUpdater          *routingtablefakes.FakeUpdater
+		fakePortChecker      *fakes.FakePortChecker
+		sender               *fake.FakeMetricSender
+		reloader             *config_reloader.ConfigReloader
+	)
+
+	writeConfig := func(contents string) {
+		err := os.WriteFile(configFilePath, []byte("haproxy_pid_file: /path/to/pid/file\n"+contents), 0600)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		configFilePath = filepath.Join(GinkgoT().TempDir(), "tcp_router.yml")
+		caFile, _ = tlshelpers.GenerateCa()
+		DeferCleanup(os.Remove, caFile)
+		writeConfig("drain_wait: 20s\nreserved_system_component_ports: [8080]\nisolation_segments: [is1]\n")
+		loadedCfg, err := config.New(configFilePath)
+		Expect(err).NotTo(HaveOccurred())
+		cfg = *loadedCfg
+
+		reload = make(chan os.Signal, 1)
+		fakeBackendTLSSetter = new(fakes.FakeBackendTLSSetter)
+		fakeUpdater = new(routingtablefakes.FakeUpdater)
+		fakePortChecker = new(fakes.FakePortChecker)
+		sender = fake.NewFakeMetricSender()
+		metrics.Initialize(sender, nil)
+	})
+
+	JustBeforeEach(func() {
+		reloader = config_reloader.New(logger, configFilePath, cfg, reload, fakeBackendTLSSetter, fakeUpdater, fakePortChecker)
+	})
+
+	Describe("Reload", func() {
+		Context("when reloadable settings change", func() {
+			BeforeEach(func() {
+				writeConfig("drain_wait: 5s\nreserved_system_component_ports: [8080, 9090]\nisolation_segments: [is1]\nbackend_tls:\n  enabled: true\n  ca_cert_path: " + caFile + "\n")
+			})
+
+			It("applies them in place and reconfigures once", func() {
+				reloader.Reload()
+
+				Expect(fakeBackendTLSSetter.SetBackendTLSCallCount()).To(Equal(1))
+				Expect(fakeBackendTLSSetter.SetBackendTLSArgsForCall(0)).To(Equal(config.BackendTLSConfig{Enabled: true, CACertificatePath: caFile}))
+
+				Expect(fakeUpdater.SetDrainWaitDurationCallCount()).To(Equal(1))
+				Expect(fakeUpdater.SetDrainWaitDurationArgsForCall(0)).To(Equal(5 * time.Second))
+
+				Expect(fakePortChecker.CheckCallCount()).To(Equal(1))
+				Expect(fakePortChecker.CheckArgsForCall(0)).To(Equal([]uint16{8080, 9090}))
+
+				Expect(fakeUpdater.ReconfigureCallCount()).To(Equal(1))
+				Expect(logger).To(gbytes.Say(`"fields":\["reserved_system_component_ports","drain_wait","backend_tls"\]`))
+				Expect(sender.GetCounter("ConfigReloads")).To(Equal(uint64(1)))
+			})
+
+			It("does not apply them again on the next reload", func() {
+				reloader.Reload()
+				reloader.Reload()
+
+				Expect(fakeBackendTLSSetter.SetBackendTLSCallCount()).To(Equal(1))
+				Expect(fakeUpdater.SetDrainWaitDurationCallCount()).To(Equal(1))
+				Expect(fakePortChecker.CheckCallCount()).To(Equal(1))
+				Expect(fakeUpdater.ReconfigureCallCount()).To(Equal(2))
+			})
+
+			Context("when the reserved ports conflict with router groups", func() {
+				BeforeEach(func() {
+					fakePortChecker.CheckReturns(true, errors.New("port 9090 is reserved"))
+				})
+
+				It("logs the conflict and still applies the other changes", func() {
+					reloader.Reload()
+
+					Expect(logger).To(gbytes.Say("router-group-port-checker-error"))
+					Expect(fakeUpdater.SetDrainWaitDurationCallCount()).To(Equal(1))
+					Expect(fakeUpdater.ReconfigureCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("when the backend tls settings are rejected", func() {
+				BeforeEach(func() {
+					fakeBackendTLSSetter.SetBackendTLSReturns(errors.New("CA file not found"))
+				})
+
+				It("does not apply any change", func() {
+					reloader.Reload()
+
+					Expect(logger).To(gbytes.Say("failed-to-apply-backend-tls"))
+					Expect(fakeUpdater.SetDrainWaitDurationCallCount()).To(Equal(0))
+					Expect(fakePortChecker.CheckCallCount()).To(Equal(0))
+					Expect(fakeUpdater.ReconfigureCallCount()).To(Equal(0))
+					Expect(sender.GetCounter("FailedConfigReloads")).To(Equal(uint64(1)))
+				})
+			})
+		})
+
+		Context("when settings that need a restart change", func() {
+			BeforeEach(func() {
+				writeConfig("drain_wait: 20s\nreserved_system_component_ports: [8080]\nisolation_segments: [is2]\n")
+			})
+
+			It("reports them without applying anything", func() {
+				reloader.Reload()
+
+				Expect(logger).To(gbytes.Say(`changes-require-restart.*"fields":\["isolation_segments"\]`))
+				Expect(fakeBackendTLSSetter.SetBackendTLSCallCount()).To(Equal(0))
+				Expect(fakeUpdater.SetDrainWaitDurationCallCount()).To(Equal(0))
+				Expect(fakePortChecker.CheckCallCount()).To(Equal(0))
+				Expect(fakeUpdater.ReconfigureCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when the config file is invalid", func() {
+			BeforeEach(func() {
+				writeConfig("drain_wait: 5s\nfrontend_ip_family: ipv5\n")
+			})
+
+			It("keeps the current config", func() {
+				reloader.Reload()
+
+				Expect(logger).To(gbytes.Say("failed-to-load-config"))
+				Expect(fakeUpdater.SetDrainWaitDurationCallCount()).To(Equal(0))
+				Expect(fakeUpdater.ReconfigureCallCount()).To(Equal(0))
+				Expect(sender.GetCounter("FailedConfigReloads")).To(Equal(uint64(1)))
+			})
+		})
+	})
+
+	Describe("Run", func() {
+		var process ifrit.Process
+
+		JustBeforeEach(func() {
+			process = ifrit.Invoke(reloader)
+		})
+
+		AfterEach(func() {
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+		})
+
+		It("reloads the config when signalled", func() {
+			reload <- syscall.SIGHUP
+			Eventually(fakeUpdater.ReconfigureCallCount).Should(Equal(1))
+		})
+	})
+})
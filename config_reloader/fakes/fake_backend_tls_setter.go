@@ -0,0 +1,112 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/cf-tcp-router/config"
+	"code.cloudfoundry.org/cf-tcp-router/config_reloader"
+)
+
+type FakeBackendTLSSetter struct {
+	SetBackendTLSStub        func(config.BackendTLSConfig) error
+	setBackendTLSMutex       sync.RWMutex
+	setBackendTLSArgsForCall []struct {
+		arg1 config.BackendTLSConfig
+	}
+	setBackendTLSReturns struct {
+		result1 error
+	}
+	setBackendTLSReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeBackendTLSSetter) SetBackendTLS(arg1 config.BackendTLSConfig) error {
+	fake.setBackendTLSMutex.Lock()
+	ret, specificReturn := fake.setBackendTLSReturnsOnCall[len(fake.setBackendTLSArgsForCall)]
+	fake.setBackendTLSArgsForCall = append(fake.setBackendTLSArgsForCall, struct {
+		arg1 config.BackendTLSConfig
+	}{arg1})
+	stub := fake.SetBackendTLSStub
+	fakeReturns := fake.setBackendTLSReturns
+	fake.recordInvocation("SetBackendTLS", []interface{}{arg1})
+	fake.setBackendTLSMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeBackendTLSSetter) SetBackendTLSCallCount() int {
+	fake.setBackendTLSMutex.RLock()
+	defer fake.setBackendTLSMutex.RUnlock()
+	return len(fake.setBackendTLSArgsForCall)
+}
+
+func (fake *FakeBackendTLSSetter) SetBackendTLSCalls(stub func(config.BackendTLSConfig) error) {
+	fake.setBackendTLSMutex.Lock()
+	defer fake.setBackendTLSMutex.Unlock()
+	fake.SetBackendTLSStub = stub
+}
+
+func (fake *FakeBackendTLSSetter) SetBackendTLSArgsForCall(i int) config.BackendTLSConfig {
+	fake.setBackendTLSMutex.RLock()
+	defer fake.setBackendTLSMutex.RUnlock()
+	argsForCall := fake.setBackendTLSArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeBackendTLSSetter) SetBackendTLSReturns(result1 error) {
+	fake.setBackendTLSMutex.Lock()
+	defer fake.setBackendTLSMutex.Unlock()
+	fake.SetBackendTLSStub = nil
+	fake.setBackendTLSReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBackendTLSSetter) SetBackendTLSReturnsOnCall(i int, result1 error) {
+	fake.setBackendTLSMutex.Lock()
+	defer fake.setBackendTLSMutex.Unlock()
+	fake.SetBackendTLSStub = nil
+	if fake.setBackendTLSReturnsOnCall == nil {
+		fake.setBackendTLSReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setBackendTLSReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBackendTLSSetter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.setBackendTLSMutex.RLock()
+	defer fake.setBackendTLSMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeBackendTLSSetter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ config_reloader.BackendTLSSetter = new(FakeBackendTLSSetter)
@@ -0,0 +1,201 @@
+package configurer
+
+import (
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/cf-tcp-router/metrics_reporter"
+	"code.cloudfoundry.org/cf-tcp-router/models"
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+var (
+	reconfigureBatchSize = metrics_reporter.Value("ReconfigureBatchSize")
+	reloadsAvoided       = metrics_reporter.Counter("ReloadsAvoided")
+)
+
+// ImmediateConfigurer is implemented by configurers that defer changes, for
+// callers that need to know whether a routing table has actually been applied.
+type ImmediateConfigurer interface {
+	ConfigureImmediately(routingTable models.RoutingTable, forceHealthCheckToFail bool) error
+}
+
+// BatchingConfigurer coalesces routing table changes and reconfigures the
+// wrapped RouterConfigurer once per batch. A batch is applied when no new
+// change has been queued for the batch window, or when its oldest change has
+// waited for the max delay, whichever comes first.
+type BatchingConfigurer struct {
+	logger   lager.Logger
+	delegate RouterConfigurer
+	clock    clock.Clock
+	window   time.Duration
+	maxDelay time.Duration
+
+	lock          *sync.Mutex
+	configureLock *sync.Mutex
+	pending       *models.RoutingTable
+	batchSize     int
+	firstQueued   time.Time
+	lastQueued    time.Time
+	flushErr      error
+	queued        chan struct{}
+}
+
+func NewBatchingConfigurer(logger lager.Logger, delegate RouterConfigurer, clock clock.Clock, window time.Duration, maxDelay time.Duration) *BatchingConfigurer {
+	return &BatchingConfigurer{
+		logger:        logger.Session("batching-configurer"),
+		delegate:      delegate,
+		clock:         clock,
+		window:        window,
+		maxDelay:      maxDelay,
+		lock:          new(sync.Mutex),
+		configureLock: new(sync.Mutex),
+		queued:        make(chan struct{}, 1),
+	}
+}
+
+// Configure queues the routing table to be applied with the current batch.
+// Requests to force the health check to fail (i.e. draining) bypass batching
+// and are applied immediately, superseding any pending batch.
+//
+// Since a queued table is applied later, Configure returns the error of the
+// last batch instead, if applying it failed and no caller has seen the error
+// yet.
+func (b *BatchingConfigurer) Configure(routingTable models.RoutingTable, forceHealthCheckToFail bool) error {
+	if forceHealthCheckToFail {
+		return b.ConfigureImmediately(routingTable, forceHealthCheckToFail)
+	}
+
+	snapshot := routingTable.Copy()
+	now := b.clock.Now()
+
+	b.lock.Lock()
+	flushErr := b.flushErr
+	b.flushErr = nil
+	if b.batchSize == 0 {
+		b.firstQueued = now
+	}
+	b.lastQueued = now
+	b.pending = &snapshot
+	b.batchSize++
+	b.lock.Unlock()
+
+	select {
+	case b.queued <- struct{}{}:
+	default:
+	}
+	return flushErr
+}
+
+// ConfigureImmediately applies the routing table without waiting for the
+// batch window, superseding any pending batch, and returns the delegate's
+// error.
+func (b *BatchingConfigurer) ConfigureImmediately(routingTable models.RoutingTable, forceHealthCheckToFail bool) error {
+	b.configureLock.Lock()
+	defer b.configureLock.Unlock()
+
+	_, batchSize := b.takePending()
+	if batchSize > 0 {
+		b.logger.Info("discarding-pending-batch", lager.Data{"batch-size": batchSize})
+		reloadsAvoided.Add(uint64(batchSize))
+	}
+	return b.delegate.Configure(routingTable, forceHealthCheckToFail)
+}
+
+func (b *BatchingConfigurer) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	b.logger.Debug("starting")
+	defer b.logger.Debug("finished")
+
+	var (
+		timer  clock.Timer
+		timerC <-chan time.Time
+	)
+	close(ready)
+	b.logger.Debug("started")
+
+	for {
+		select {
+		case <-b.queued:
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = b.clock.NewTimer(b.timeUntilFlush())
+			timerC = timer.C()
+
+		case <-timerC:
+			if delay := b.timeUntilFlush(); delay > 0 {
+				timer = b.clock.NewTimer(delay)
+				timerC = timer.C()
+				continue
+			}
+			timerC = nil
+			b.flush()
+
+		case sig := <-signals:
+			if sig != syscall.SIGUSR2 {
+				b.logger.Info("stopping")
+				if timer != nil {
+					timer.Stop()
+				}
+				b.flush()
+				return nil
+			}
+		}
+	}
+}
+
+func (b *BatchingConfigurer) timeUntilFlush() time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.batchSize == 0 {
+		return 0
+	}
+
+	deadline := b.lastQueued.Add(b.window)
+	if capped := b.firstQueued.Add(b.maxDelay); capped.Before(deadline) {
+		deadline = capped
+	}
+
+	delay := deadline.Sub(b.clock.Now())
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+func (b *BatchingConfigurer) takePending() (*models.RoutingTable, int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	pending, batchSize := b.pending, b.batchSize
+	b.pending = nil
+	b.batchSize = 0
+	return pending, batchSize
+}
+
+func (b *BatchingConfigurer) flush() {
+	b.configureLock.Lock()
+	defer b.configureLock.Unlock()
+
+	pending, batchSize := b.takePending()
+	if pending == nil {
+		return
+	}
+
+	b.logger.Info("applying-batch", lager.Data{"batch-size": batchSize})
+	reconfigureBatchSize.Send(uint64(batchSize))
+	reloadsAvoided.Add(uint64(batchSize - 1))
+
+	err := b.delegate.Configure(*pending, false)
+	if err != nil {
+		b.logger.Error("failed-to-apply-batch", err)
+	}
+
+	b.lock.Lock()
+	b.flushErr = err
+	b.lock.Unlock()
+}
@@ -0,0 +1,223 @@
+package configurer_test
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/cf-tcp-router/configurer"
+	"code.cloudfoundry.org/cf-tcp-router/configurer/fakes"
+	"code.cloudfoundry.org/cf-tcp-router/models"
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/cloudfoundry/dropsonde/metric_sender/fake"
+	"github.com/cloudfoundry/dropsonde/metrics"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("BatchingConfigurer", func() {
+	const (
+		window   = 1 * time.Second
+		maxDelay = 3 * time.Second
+	)
+
+	var (
+		fakeDelegate       *fakes.FakeRouterConfigurer
+		fakeClock          *fakeclock.FakeClock
+		sender             *fake.FakeMetricSender
+		batchingConfigurer *configurer.BatchingConfigurer
+		process            ifrit.Process
+		routingTable       models.RoutingTable
+	)
+
+	tableWithPorts := func(ports ...uint16) models.RoutingTable {
+		table := models.NewRoutingTable(logger)
+		for _, port := range ports {
+			table.UpsertBackendServerKey(models.RoutingKey{Port: port}, models.BackendServerInfo{Address: "some-ip", Port: 1234})
+		}
+		return table
+	}
+
+	BeforeEach(func() {
+		fakeDelegate = new(fakes.FakeRouterConfigurer)
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		sender = fake.NewFakeMetricSender()
+		metrics.Initialize(sender, nil)
+		routingTable = tableWithPorts(2222)
+
+		batchingConfigurer = configurer.NewBatchingConfigurer(logger, fakeDelegate, fakeClock, window, maxDelay)
+		process = ifrit.Invoke(batchingConfigurer)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive(BeNil()))
+	})
+
+	Context("when a single change is queued", func() {
+		BeforeEach(func() {
+			Expect(batchingConfigurer.Configure(routingTable, false)).To(Succeed())
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+		})
+
+		It("waits for the batch window before configuring", func() {
+			Consistently(fakeDelegate.ConfigureCallCount).Should(Equal(0))
+
+			fakeClock.Increment(window)
+			Eventually(fakeDelegate.ConfigureCallCount).Should(Equal(1))
+			configuredTable, forceHealthCheckToFail := fakeDelegate.ConfigureArgsForCall(0)
+			Expect(configuredTable.Entries).To(Equal(routingTable.Entries))
+			Expect(forceHealthCheckToFail).To(BeFalse())
+		})
+
+		It("applies a snapshot of the table as it was when queued", func() {
+			delete(routingTable.Entries, models.RoutingKey{Port: 2222})
+
+			fakeClock.Increment(window)
+			Eventually(fakeDelegate.ConfigureCallCount).Should(Equal(1))
+			configuredTable, _ := fakeDelegate.ConfigureArgsForCall(0)
+			Expect(configuredTable.Entries).To(HaveKey(models.RoutingKey{Port: 2222}))
+		})
+
+		It("emits the batch size", func() {
+			fakeClock.Increment(window)
+			Eventually(func() fake.Metric {
+				return sender.GetValue("ReconfigureBatchSize")
+			}).Should(Equal(fake.Metric{Value: float64(1), Unit: "Metric"}))
+			Expect(sender.GetCounter("ReloadsAvoided")).To(Equal(uint64(0)))
+		})
+
+		Context("when the delegate returns an error", func() {
+			BeforeEach(func() {
+				fakeDelegate.ConfigureReturns(errors.New("kaboom"))
+			})
+
+			It("logs the error", func() {
+				fakeClock.Increment(window)
+				Eventually(logger).Should(gbytes.Say("failed-to-apply-batch"))
+			})
+
+			It("returns the error from the next Configure only", func() {
+				fakeClock.Increment(window)
+				Eventually(fakeDelegate.ConfigureCallCount).Should(Equal(1))
+				Eventually(func() error {
+					return batchingConfigurer.Configure(routingTable, false)
+				}).Should(MatchError("kaboom"))
+				Expect(batchingConfigurer.Configure(routingTable, false)).To(Succeed())
+			})
+		})
+	})
+
+	Context("when several changes are queued within the batch window", func() {
+		BeforeEach(func() {
+			Expect(batchingConfigurer.Configure(tableWithPorts(2222), false)).To(Succeed())
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.Increment(window / 2)
+			Expect(batchingConfigurer.Configure(tableWithPorts(2222, 2223), false)).To(Succeed())
+			fakeClock.Increment(window / 2)
+			Expect(batchingConfigurer.Configure(tableWithPorts(2222, 2223, 2224), false)).To(Succeed())
+		})
+
+		It("configures once with the latest table", func() {
+			Consistently(fakeDelegate.ConfigureCallCount).Should(Equal(0))
+
+			fakeClock.Increment(window)
+			Eventually(fakeDelegate.ConfigureCallCount).Should(Equal(1))
+			Consistently(fakeDelegate.ConfigureCallCount).Should(Equal(1))
+			configuredTable, _ := fakeDelegate.ConfigureArgsForCall(0)
+			Expect(configuredTable.Size()).To(Equal(3))
+		})
+
+		It("emits the batch size and the reloads avoided", func() {
+			fakeClock.Increment(window)
+			Eventually(func() fake.Metric {
+				return sender.GetValue("ReconfigureBatchSize")
+			}).Should(Equal(fake.Metric{Value: float64(3), Unit: "Metric"}))
+			Expect(sender.GetCounter("ReloadsAvoided")).To(Equal(uint64(2)))
+		})
+	})
+
+	Context("when changes keep arriving for longer than the max delay", func() {
+		It("configures once the max delay has elapsed", func() {
+			Expect(batchingConfigurer.Configure(tableWithPorts(2222), false)).To(Succeed())
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+
+			for elapsed := time.Duration(0); elapsed < maxDelay; elapsed += window / 2 {
+				Expect(fakeDelegate.ConfigureCallCount()).To(Equal(0))
+				fakeClock.Increment(window / 2)
+				Expect(batchingConfigurer.Configure(tableWithPorts(2222, 2223), false)).To(Succeed())
+			}
+
+			Eventually(fakeDelegate.ConfigureCallCount).Should(Equal(1))
+		})
+	})
+
+	Context("when the health check is forced to fail", func() {
+		BeforeEach(func() {
+			Expect(batchingConfigurer.Configure(tableWithPorts(2222), false)).To(Succeed())
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+		})
+
+		It("configures immediately and discards the pending batch", func() {
+			Expect(batchingConfigurer.Configure(tableWithPorts(2222, 2223), true)).To(Succeed())
+			Expect(fakeDelegate.ConfigureCallCount()).To(Equal(1))
+			configuredTable, forceHealthCheckToFail := fakeDelegate.ConfigureArgsForCall(0)
+			Expect(configuredTable.Size()).To(Equal(2))
+			Expect(forceHealthCheckToFail).To(BeTrue())
+
+			fakeClock.Increment(window)
+			Consistently(fakeDelegate.ConfigureCallCount).Should(Equal(1))
+			Expect(sender.GetCounter("ReloadsAvoided")).To(Equal(uint64(1)))
+		})
+
+		It("returns the delegate's error", func() {
+			fakeDelegate.ConfigureReturns(errors.New("kaboom"))
+			Expect(batchingConfigurer.Configure(routingTable, true)).To(MatchError("kaboom"))
+		})
+	})
+
+	Context("when a change is applied immediately", func() {
+		BeforeEach(func() {
+			Expect(batchingConfigurer.Configure(tableWithPorts(2222), false)).To(Succeed())
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+		})
+
+		It("configures without waiting and discards the pending batch", func() {
+			Expect(batchingConfigurer.ConfigureImmediately(tableWithPorts(2222, 2223), false)).To(Succeed())
+			Expect(fakeDelegate.ConfigureCallCount()).To(Equal(1))
+			configuredTable, forceHealthCheckToFail := fakeDelegate.ConfigureArgsForCall(0)
+			Expect(configuredTable.Size()).To(Equal(2))
+			Expect(forceHealthCheckToFail).To(BeFalse())
+
+			fakeClock.Increment(window)
+			Consistently(fakeDelegate.ConfigureCallCount).Should(Equal(1))
+		})
+
+		It("returns the delegate's error", func() {
+			fakeDelegate.ConfigureReturns(errors.New("kaboom"))
+			Expect(batchingConfigurer.ConfigureImmediately(routingTable, false)).To(MatchError("kaboom"))
+		})
+	})
+
+	Context("when signaled with SIGUSR2", func() {
+		It("does not shut down", func() {
+			process.Signal(syscall.SIGUSR2)
+			Consistently(process.Wait()).ShouldNot(Receive())
+		})
+	})
+
+	Context("when stopped with a pending batch", func() {
+		It("applies the pending batch before exiting", func() {
+			Expect(batchingConfigurer.Configure(routingTable, false)).To(Succeed())
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+			Expect(fakeDelegate.ConfigureCallCount()).To(Equal(1))
+		})
+	})
+})
@@ -19,17 +19,19 @@ type RouterConfigurer interface {
 	Configure(routingTable models.RoutingTable, forceHealthCheckToFail bool) error
 }
 
-func NewConfigurer(logger lager.Logger, tcpLoadBalancer string, tcpLoadBalancerBaseCfg string, tcpLoadBalancerCfg string, monitor monitor.Monitor, scriptRunner haproxy.ScriptRunner, backendTlsCfg config.BackendTLSConfig) RouterConfigurer {
+func NewConfigurer(logger lager.Logger, tcpLoadBalancer string, tcpLoadBalancerBaseCfg string, tcpLoadBalancerCfg string, monitor monitor.Monitor, scriptRunner haproxy.ScriptRunner, configValidator haproxy.ConfigValidator, runtimeAPI haproxy.RuntimeAPI, cfg config.Config) RouterConfigurer {
 	switch tcpLoadBalancer {
 	case HaProxyConfigurer:
 		routerHostInfo, err := haproxy.NewHaProxyConfigurer(
 			logger,
-			haproxy.NewConfigMarshaller(logger),
+			haproxy.NewConfigMarshaller(logger, cfg),
 			tcpLoadBalancerBaseCfg,
 			tcpLoadBalancerCfg,
 			monitor,
 			scriptRunner,
-			backendTlsCfg,
+			configValidator,
+			runtimeAPI,
+			cfg,
 		)
 
 		if err != nil {
@@ -28,7 +28,7 @@ var _ = Describe("Configurer", func() {
 		Context("when 'haproxy' tcp load balancer is passed", func() {
 			It("should return haproxy configurer", func() {
 				routeConfigurer := configurer.NewConfigurer(logger,
-					configurer.HaProxyConfigurer, "haproxy/fixtures/haproxy.cfg.template", "haproxy/fixtures/haproxy.cfg", nil, nil, backendTlsCfg)
+					configurer.HaProxyConfigurer, "haproxy/fixtures/haproxy.cfg.template", "haproxy/fixtures/haproxy.cfg", nil, nil, nil, nil, config.Config{BackendTLS: backendTlsCfg})
 				Expect(routeConfigurer).ShouldNot(BeNil())
 				expectedType := reflect.PointerTo(reflect.TypeOf(haproxy.Configurer{}))
 				value := reflect.ValueOf(routeConfigurer)
@@ -38,7 +38,7 @@ var _ = Describe("Configurer", func() {
 			Context("when invalid config file is passed", func() {
 				It("should panic", func() {
 					Expect(func() {
-						configurer.NewConfigurer(logger, configurer.HaProxyConfigurer, "haproxy/fixtures/haproxy.cfg.template", "", nil, nil, backendTlsCfg)
+						configurer.NewConfigurer(logger, configurer.HaProxyConfigurer, "haproxy/fixtures/haproxy.cfg.template", "", nil, nil, nil, nil, config.Config{BackendTLS: backendTlsCfg})
 					}).Should(Panic())
 				})
 			})
@@ -46,7 +46,7 @@ var _ = Describe("Configurer", func() {
 			Context("when invalid base config file is passed", func() {
 				It("should panic", func() {
 					Expect(func() {
-						configurer.NewConfigurer(logger, configurer.HaProxyConfigurer, "", "haproxy/fixtures/haproxy.cfg", nil, nil, backendTlsCfg)
+						configurer.NewConfigurer(logger, configurer.HaProxyConfigurer, "", "haproxy/fixtures/haproxy.cfg", nil, nil, nil, nil, config.Config{BackendTLS: backendTlsCfg})
 					}).Should(Panic())
 				})
 			})
@@ -54,7 +54,7 @@ var _ = Describe("Configurer", func() {
 			Context("when invalid CA file is passed", func() {
 				It("should panic", func() {
 					Expect(func() {
-						configurer.NewConfigurer(logger, configurer.HaProxyConfigurer, "haproxy/fixtures/haproxy.cfg.template", "haproxy/fixtures/haproxy.cfg", nil, nil, config.BackendTLSConfig{CACertificatePath: "nonexistent/file"})
+						configurer.NewConfigurer(logger, configurer.HaProxyConfigurer, "haproxy/fixtures/haproxy.cfg.template", "haproxy/fixtures/haproxy.cfg", nil, nil, nil, nil, config.Config{BackendTLS: config.BackendTLSConfig{CACertificatePath: "nonexistent/file"}})
 					}).Should(Panic())
 				})
 			})
@@ -62,14 +62,14 @@ var _ = Describe("Configurer", func() {
 			Context("when invalid ClientCertAndKey file is passed", func() {
 				It("should panic", func() {
 					Expect(func() {
-						configurer.NewConfigurer(logger, configurer.HaProxyConfigurer, "haproxy/fixtures/haproxy.cfg.template", "haproxy/fixtures/haproxy.cfg", nil, nil, config.BackendTLSConfig{ClientCertAndKeyPath: "nonexistent/file"})
+						configurer.NewConfigurer(logger, configurer.HaProxyConfigurer, "haproxy/fixtures/haproxy.cfg.template", "haproxy/fixtures/haproxy.cfg", nil, nil, nil, nil, config.Config{BackendTLS: config.BackendTLSConfig{ClientCertAndKeyPath: "nonexistent/file"}})
 					}).Should(Panic())
 				})
 			})
 			Context("when empty CA + ClientCertAndKey paths are passed", func() {
 				It("should not panic", func() {
 					Expect(func() {
-						configurer.NewConfigurer(logger, configurer.HaProxyConfigurer, "haproxy/fixtures/haproxy.cfg.template", "haproxy/fixtures/haproxy.cfg", nil, nil, config.BackendTLSConfig{})
+						configurer.NewConfigurer(logger, configurer.HaProxyConfigurer, "haproxy/fixtures/haproxy.cfg.template", "haproxy/fixtures/haproxy.cfg", nil, nil, nil, nil, config.Config{BackendTLS: config.BackendTLSConfig{}})
 					}).ShouldNot(Panic())
 				})
 			})
@@ -78,7 +78,7 @@ var _ = Describe("Configurer", func() {
 		Context("when non-supported tcp load balancer is passed", func() {
 			It("should panic", func() {
 				Expect(func() {
-					configurer.NewConfigurer(logger, "not-supported", "some-base-config-file", "some-config-file", nil, nil, backendTlsCfg)
+					configurer.NewConfigurer(logger, "not-supported", "some-base-config-file", "some-config-file", nil, nil, nil, nil, config.Config{BackendTLS: backendTlsCfg})
 				}).Should(Panic())
 			})
 		})
@@ -86,7 +86,7 @@ var _ = Describe("Configurer", func() {
 		Context("when empty tcp load balancer is passed", func() {
 			It("should panic", func() {
 				Expect(func() {
-					configurer.NewConfigurer(logger, "", "some-base-config-file", "some-config-file", nil, nil, backendTlsCfg)
+					configurer.NewConfigurer(logger, "", "some-base-config-file", "some-config-file", nil, nil, nil, nil, config.Config{BackendTLS: backendTlsCfg})
 				}).Should(Panic())
 			})
 		})
@@ -2,8 +2,11 @@ package haproxy
 
 import (
 	"fmt"
+	"net"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"code.cloudfoundry.org/cf-tcp-router/config"
 	"code.cloudfoundry.org/cf-tcp-router/models"
@@ -15,12 +18,17 @@ type ConfigMarshaller interface {
 	Marshal(models.HAProxyConfig, config.BackendTLSConfig) string
 }
 
+// Servers added through the runtime API occupy pre-allocated slots named
+// slot_1 through slot_N, rendered with server-template.
+const serverSlotPrefix = "slot_"
+
 type configMarshaller struct {
 	logger lager.Logger
+	cfg    config.Config
 }
 
-func NewConfigMarshaller(l lager.Logger) ConfigMarshaller {
-	return configMarshaller{logger: l}
+func NewConfigMarshaller(l lager.Logger, cfg config.Config) ConfigMarshaller {
+	return configMarshaller{logger: l, cfg: cfg}
 }
 
 func (cm configMarshaller) Marshal(conf models.HAProxyConfig, backendTlsCfg config.BackendTLSConfig) string {
@@ -40,11 +48,56 @@ func (cm configMarshaller) marshalHAProxyFrontend(port models.HAProxyInboundPort
 		frontendStanza strings.Builder
 		backendStanzas strings.Builder
 	)
-	frontendStanza.WriteString(fmt.Sprintf("\nfrontend frontend_%d", port))
+	frontendStanza.WriteString(fmt.Sprintf("\nfrontend %s", models.FrontendProxyName(port)))
 	frontendStanza.WriteString("\n  mode tcp")
-	frontendStanza.WriteString(fmt.Sprintf("\n  bind :%d", port))
+	frontendStanza.WriteString(fmt.Sprintf("\n  bind %s", cm.bindAddress(port)))
+	terminateTLS := cm.cfg.TLSTermination.Terminates(uint16(port))
+	if terminateTLS {
+		frontendStanza.WriteString(fmt.Sprintf(" ssl crt %s", cm.cfg.TLSTermination.CertDir))
+	}
+	if cm.cfg.ProxyProtocol.Accept {
+		frontendStanza.WriteString(" accept-proxy")
+	}
+
+	if profile, ok := cm.cfg.Timeouts.ProfileForPort(uint16(port)); ok {
+		writeTimeout(&frontendStanza, "client", profile.Client)
+		writeTimeout(&frontendStanza, "client-fin", profile.ClientFin)
+		if profile.FrontendMaxConn > 0 {
+			frontendStanza.WriteString(fmt.Sprintf("\n  maxconn %d", profile.FrontendMaxConn))
+		}
+	}
+
+	// Once TLS is terminated the SNI hostname is known without inspecting the
+	// client hello
+	sniFetch := "ssl_fc_sni"
+	if !terminateTLS {
+		sniFetch = "req.ssl_sni"
+	}
+
+	if rule, ok := cm.cfg.AccessControl.RuleForPort(uint16(port)); ok {
+		if len(rule.Deny) > 0 {
+			frontendStanza.WriteString(fmt.Sprintf("\n  tcp-request %s reject if { src %s }", cm.sourceRuleSet(), strings.Join(rule.Deny, " ")))
+		}
+		if len(rule.Allow) > 0 {
+			frontendStanza.WriteString(fmt.Sprintf("\n  tcp-request %s reject unless { src %s }", cm.sourceRuleSet(), strings.Join(rule.Allow, " ")))
+		}
+	}
+
+	if rule, ok := cm.cfg.RateLimit.RuleForPort(uint16(port)); ok {
+		period := rule.RatePeriod.Milliseconds()
+		frontendStanza.WriteString(fmt.Sprintf("\n  stick-table type ipv6 size %d expire %dms store conn_rate(%dms),conn_cur", cm.cfg.RateLimit.TableSize, period, period))
+		frontendStanza.WriteString(fmt.Sprintf("\n  tcp-request %s track-sc0 src", cm.sourceRuleSet()))
+		// Limits are enforced by content rules so that HAProxy counts rate
+		// limited connections apart from those denied by access control
+		if rule.ConnectionRate > 0 {
+			frontendStanza.WriteString(fmt.Sprintf("\n  tcp-request content reject if { sc_conn_rate(0) gt %d }", rule.ConnectionRate))
+		}
+		if rule.ConcurrentConnections > 0 {
+			frontendStanza.WriteString(fmt.Sprintf("\n  tcp-request content reject if { sc_conn_cur(0) gt %d }", rule.ConcurrentConnections))
+		}
+	}
 
-	if frontend.ContainsSNIRoutes() {
+	if frontend.ContainsSNIRoutes() && !terminateTLS {
 		frontendStanza.WriteString("\n  tcp-request inspect-delay 5s")
 		frontendStanza.WriteString("\n  tcp-request content accept if { req.ssl_hello_type gt 0 }")
 	}
@@ -54,18 +107,17 @@ func (cm configMarshaller) marshalHAProxyFrontend(port models.HAProxyInboundPort
 		var backendCfgName string
 		hostname := sortedHostnames[hostnameIdx]
 
+		backendCfgName = models.BackendProxyName(port, hostname)
 		if hostname == "" { // The non-SNI route gets a default_backend because none of the `use_backend if {...}` predicates will succeed
-			backendCfgName = fmt.Sprintf("backend_%d", port)
 			frontendStanza.WriteString(fmt.Sprintf("\n  default_backend %s", backendCfgName))
 
 		} else { // SNI routes use named backends
-			backendCfgName = fmt.Sprintf("backend_%d_%s", port, hostname)
-			frontendStanza.WriteString(fmt.Sprintf("\n  use_backend %s if { req.ssl_sni %s }", backendCfgName, hostname))
+			frontendStanza.WriteString(fmt.Sprintf("\n  use_backend %s if { %s }", backendCfgName, sniMatch(sniFetch, hostname)))
 		}
 
 		backend := frontend[hostname]
 
-		haProxyBackendString := cm.marshalHAProxyBackend(backendCfgName, backend, backendTlsCfg)
+		haProxyBackendString := cm.marshalHAProxyBackend(port, hostname, backendCfgName, backend, backendTlsCfg)
 		backendStanzas.WriteString(haProxyBackendString)
 	}
 
@@ -74,13 +126,53 @@ func (cm configMarshaller) marshalHAProxyFrontend(port models.HAProxyInboundPort
 	return frontendStanza.String()
 }
 
+// sourceRuleSet returns the tcp-request rule set in which src is the client
+// address. Connection rules run before the PROXY protocol header is read, so
+// src would be the address of the load balancer in front of the router.
+func (cm configMarshaller) sourceRuleSet() string {
+	if cm.cfg.ProxyProtocol.Accept {
+		return "session"
+	}
+	return "connection"
+}
+
 // This might result in malformed lines since we always write the opening stanza, but conditionally write others...
-func (cm configMarshaller) marshalHAProxyBackend(backendName string, backend models.HAProxyBackend, backendTlsCfg config.BackendTLSConfig) string {
+func (cm configMarshaller) marshalHAProxyBackend(port models.HAProxyInboundPort, hostname models.SniHostname, backendName string, backend models.HAProxyBackend, backendTlsCfg config.BackendTLSConfig) string {
 	var output strings.Builder
 
 	output.WriteString(fmt.Sprintf("\nbackend %s", backendName))
 	output.WriteString("\n  mode tcp")
 
+	if algorithm := cm.cfg.LoadBalancing.AlgorithmForPort(uint16(port)); algorithm != "" {
+		output.WriteString(fmt.Sprintf("\n  balance %s", algorithm))
+	}
+
+	profile, hasProfile := cm.cfg.Timeouts.ProfileForPort(uint16(port))
+	if hasProfile {
+		writeTimeout(&output, "connect", profile.Connect)
+		writeTimeout(&output, "server", profile.Server)
+		writeTimeout(&output, "tunnel", profile.Tunnel)
+	}
+
+	// Options shared by every server, including the server slots
+	var defaultServerOptions []string
+	if hasProfile && profile.ServerMaxConn > 0 {
+		defaultServerOptions = append(defaultServerOptions, fmt.Sprintf("maxconn %d", profile.ServerMaxConn))
+	}
+	healthCheck := cm.cfg.HealthCheck
+	if healthCheck.Enabled {
+		defaultServerOptions = append(defaultServerOptions, fmt.Sprintf("inter %dms rise %d fall %d", healthCheck.Interval.Milliseconds(), healthCheck.Rise, healthCheck.Fall))
+	}
+	switch cm.cfg.ProxyProtocol.VersionForRoute(uint16(port), string(hostname)) {
+	case config.ProxyProtocolV1:
+		defaultServerOptions = append(defaultServerOptions, "send-proxy")
+	case config.ProxyProtocolV2:
+		defaultServerOptions = append(defaultServerOptions, "send-proxy-v2")
+	}
+	if len(defaultServerOptions) > 0 {
+		output.WriteString(fmt.Sprintf("\n  default-server %s", strings.Join(defaultServerOptions, " ")))
+	}
+
 	for _, server := range backend {
 		if server.TLSPort > 0 && !backendTlsCfg.Enabled {
 			cm.logger.Error("backend-tls-not-enabled", fmt.Errorf("Backend TLS Port was set, but backend_tls has not been enabled for tcp-router"), lager.Data{"backend": backend})
@@ -89,16 +181,40 @@ func (cm configMarshaller) marshalHAProxyBackend(backendName string, backend mod
 		}
 
 		if server.TLSPort > 0 {
-			output.WriteString(fmt.Sprintf("\n  server server_%s_%d %s:%d ssl verify required verifyhost %s ca-file %s", server.Address, server.TLSPort, server.Address, server.TLSPort, server.InstanceID, backendTlsCfg.CACertificatePath))
+			output.WriteString(fmt.Sprintf("\n  server %s %s ssl verify required verifyhost %s ca-file %s", serverName(server), serverAddress(server.Address, server.TLSPort), server.InstanceID, backendTlsCfg.CACertificatePath))
 
 			if backendTlsCfg.ClientCertAndKeyPath != "" {
 				output.WriteString(fmt.Sprintf(" crt %s", backendTlsCfg.ClientCertAndKeyPath))
 			}
+
+			if healthCheck.Enabled {
+				// Checks of ssl servers use TLS unless told otherwise
+				if healthCheck.TLSHandshake {
+					output.WriteString(" check check-ssl")
+				} else {
+					output.WriteString(" check no-check-ssl")
+				}
+			}
 		} else {
 			if server.TLSPort == 0 && backendTlsCfg.Enabled {
 				cm.logger.Error("route-missing-tls-information", fmt.Errorf("Backend TLSPort was set to 0. If TLS is intentionally off for this backend, set this to -1 to suppress this message"), lager.Data{"backend": server})
 			}
-			output.WriteString(fmt.Sprintf("\n  server server_%s_%d %s:%d", server.Address, server.Port, server.Address, server.Port))
+			output.WriteString(fmt.Sprintf("\n  server %s %s", serverName(server), serverAddress(server.Address, int(server.Port))))
+
+			if healthCheck.Enabled {
+				output.WriteString(" check")
+			}
+		}
+	}
+
+	if cm.cfg.RuntimeAPI.Enabled {
+		// Placeholder address; slots are pointed at real servers through the
+		// runtime API. The port must not be 0, which would make HAProxy treat
+		// the slots as port-mapped and refuse to set their address and port.
+		output.WriteString(fmt.Sprintf("\n  server-template %s 1-%d 0.0.0.0:1 disabled", serverSlotPrefix, cm.cfg.RuntimeAPI.ServerSlots))
+
+		if healthCheck.Enabled {
+			output.WriteString(" check")
 		}
 	}
 
@@ -106,6 +222,41 @@ func (cm configMarshaller) marshalHAProxyBackend(backendName string, backend mod
 	return output.String()
 }
 
+func writeTimeout(output *strings.Builder, name string, timeout time.Duration) {
+	if timeout > 0 {
+		output.WriteString(fmt.Sprintf("\n  timeout %s %dms", name, timeout.Milliseconds()))
+	}
+}
+
+func (cm configMarshaller) bindAddress(port models.HAProxyInboundPort) string {
+	switch cm.cfg.FrontendIPFamily {
+	case config.FrontendIPv6:
+		return fmt.Sprintf(":::%d v6only", port)
+	case config.FrontendDualStack:
+		return fmt.Sprintf(":::%d v4v6", port)
+	default:
+		return fmt.Sprintf(":%d", port)
+	}
+}
+
+// serverAddress brackets IPv6 addresses so the port separator is unambiguous
+func serverAddress(address string, port int) string {
+	return net.JoinHostPort(address, strconv.Itoa(port))
+}
+
+func serverName(server models.HAProxyServer) string {
+	// Spell IPv6 addresses with dashes so names look alike for both address families
+	address := strings.ReplaceAll(server.Address, ":", "-")
+	if server.TLSPort > 0 {
+		return fmt.Sprintf("server_%s_%d", address, server.TLSPort)
+	}
+	return fmt.Sprintf("server_%s_%d", address, server.Port)
+}
+
+func serverSlotName(slot int) string {
+	return fmt.Sprintf("%s%d", serverSlotPrefix, slot)
+}
+
 func sortedHAProxyInboundPorts(conf models.HAProxyConfig) []models.HAProxyInboundPort {
 	keys := make([]models.HAProxyInboundPort, len(conf))
 	i := 0
@@ -118,6 +269,17 @@ func sortedHAProxyInboundPorts(conf models.HAProxyConfig) []models.HAProxyInboun
 	return keys
 }
 
+// sniMatch matches wildcard hostnames by suffix and others exactly
+func sniMatch(sniFetch string, hostname models.SniHostname) string {
+	if hostname.IsWildcard() {
+		return fmt.Sprintf("%s -m end %s", sniFetch, hostname.Suffix())
+	}
+	return fmt.Sprintf("%s %s", sniFetch, hostname)
+}
+
+// sortedSniHostnames orders hostnames the way their use_backend rules must be
+// evaluated: exact hostnames first, then wildcards from the longest suffix to
+// the shortest, so the most specific route wins.
 func sortedSniHostnames(frontend models.HAProxyFrontend) []models.SniHostname {
 	keys := make([]models.SniHostname, len(frontend))
 	i := 0
@@ -126,6 +288,14 @@ func sortedSniHostnames(frontend models.HAProxyFrontend) []models.SniHostname {
 		i++
 	}
 
-	sort.SliceStable(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].IsWildcard() != keys[j].IsWildcard() {
+			return !keys[i].IsWildcard()
+		}
+		if keys[i].IsWildcard() && len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
 	return keys
 }
@@ -1,6 +1,8 @@
 package haproxy_test
 
 import (
+	"time"
+
 	"code.cloudfoundry.org/cf-tcp-router/config"
 	"code.cloudfoundry.org/cf-tcp-router/configurer/haproxy"
 	"code.cloudfoundry.org/cf-tcp-router/models"
@@ -23,7 +25,7 @@ var _ = Describe("ConfigMarshaller", func() {
 		BeforeEach(func() {
 			logger = lagertest.NewTestLogger("config-marshaller-test")
 			haproxyConf = models.HAProxyConfig{}
-			marshaller = haproxy.NewConfigMarshaller(logger)
+			marshaller = haproxy.NewConfigMarshaller(logger, config.Config{})
 			backendTlsCfg = config.BackendTLSConfig{
 				Enabled:           false,
 				CACertificatePath: "/fake/path/to/ca.pem",
@@ -349,5 +351,585 @@ backend backend_80
 				})
 			})
 		})
+
+		Context("when a server has an IPv6 address", func() {
+			It("brackets the address and keeps the server name free of colons", func() {
+				haproxyConf = models.HAProxyConfig{
+					80: {
+						"": {{Address: "fd00::1", Port: 8080}},
+					},
+				}
+
+				Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(Equal(`
+frontend frontend_80
+  mode tcp
+  bind :80
+  default_backend backend_80
+
+backend backend_80
+  mode tcp
+  server server_fd00--1_8080 [fd00::1]:8080
+`))
+			})
+
+			Context("when backend_tls is enabled", func() {
+				It("brackets the address of the TLS server", func() {
+					haproxyConf = models.HAProxyConfig{
+						80: {
+							"": {{Address: "fd00::1", Port: 8080, TLSPort: 8443, InstanceID: "instance-id"}},
+						},
+					}
+
+					Expect(marshaller.Marshal(haproxyConf, config.BackendTLSConfig{Enabled: true, CACertificatePath: "/ca.pem"})).To(ContainSubstring(
+						"\n  server server_fd00--1_8443 [fd00::1]:8443 ssl verify required verifyhost instance-id ca-file /ca.pem\n"))
+				})
+			})
+		})
+
+		Context("when frontend_ip_family is set", func() {
+			BeforeEach(func() {
+				haproxyConf = models.HAProxyConfig{
+					80: {
+						"": {{Address: "default-host.internal", Port: 8080}},
+					},
+				}
+			})
+
+			It("binds IPv6 only frontends", func() {
+				marshaller = haproxy.NewConfigMarshaller(logger, config.Config{FrontendIPFamily: config.FrontendIPv6})
+				Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(ContainSubstring("\n  bind :::80 v6only\n"))
+			})
+
+			It("binds dual-stack frontends", func() {
+				marshaller = haproxy.NewConfigMarshaller(logger, config.Config{FrontendIPFamily: config.FrontendDualStack})
+				Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(ContainSubstring("\n  bind :::80 v4v6\n"))
+			})
+
+			It("binds IPv4 frontends", func() {
+				marshaller = haproxy.NewConfigMarshaller(logger, config.Config{FrontendIPFamily: config.FrontendIPv4})
+				Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(ContainSubstring("\n  bind :80\n"))
+			})
+		})
+
+		Context("when the runtime api is enabled", func() {
+			BeforeEach(func() {
+				marshaller = haproxy.NewConfigMarshaller(logger, config.Config{
+					RuntimeAPI: config.RuntimeAPIConfig{Enabled: true, ServerSlots: 5},
+				})
+			})
+
+			It("adds a server template with the configured number of slots to each backend", func() {
+				haproxyConf = models.HAProxyConfig{
+					80: {
+						"":                          {{Address: "default-host.internal", Port: 8080}},
+						"external-host.example.com": {{Address: "sni-host.internal", Port: 9090}},
+					},
+				}
+
+				Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(Equal(`
+frontend frontend_80
+  mode tcp
+  bind :80
+  tcp-request inspect-delay 5s
+  tcp-request content accept if { req.ssl_hello_type gt 0 }
+  default_backend backend_80
+  use_backend backend_80_external-host.example.com if { req.ssl_sni external-host.example.com }
+
+backend backend_80
+  mode tcp
+  server server_default-host.internal_8080 default-host.internal:8080
+  server-template slot_ 1-5 0.0.0.0:1 disabled
+
+backend backend_80_external-host.example.com
+  mode tcp
+  server server_sni-host.internal_9090 sni-host.internal:9090
+  server-template slot_ 1-5 0.0.0.0:1 disabled
+`))
+			})
+		})
+
+		Context("when health checks are enabled", func() {
+			BeforeEach(func() {
+				marshaller = haproxy.NewConfigMarshaller(logger, config.Config{
+					HealthCheck: config.HealthCheckConfig{Enabled: true, Interval: 2 * time.Second, Rise: 2, Fall: 3},
+				})
+				haproxyConf = models.HAProxyConfig{
+					80: {
+						"": {
+							{Address: "default-host.internal", Port: 8080},
+							{Address: "tls-host.internal", Port: 8888, TLSPort: 8443, InstanceID: "tls-host-instance-id"},
+						},
+					},
+				}
+				backendTlsCfg = config.BackendTLSConfig{Enabled: true, CACertificatePath: "/ca.pem"}
+			})
+
+			It("checks every server with the configured thresholds", func() {
+				Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(Equal(`
+frontend frontend_80
+  mode tcp
+  bind :80
+  default_backend backend_80
+
+backend backend_80
+  mode tcp
+  default-server inter 2000ms rise 2 fall 3
+  server server_default-host.internal_8080 default-host.internal:8080 check
+  server server_tls-host.internal_8443 tls-host.internal:8443 ssl verify required verifyhost tls-host-instance-id ca-file /ca.pem check no-check-ssl
+`))
+			})
+
+			Context("when TLS handshake checks are enabled", func() {
+				BeforeEach(func() {
+					marshaller = haproxy.NewConfigMarshaller(logger, config.Config{
+						HealthCheck: config.HealthCheckConfig{Enabled: true, Interval: 2 * time.Second, Rise: 2, Fall: 3, TLSHandshake: true},
+					})
+				})
+
+				It("checks TLS servers with a handshake", func() {
+					Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(ContainSubstring(
+						"\n  server server_tls-host.internal_8443 tls-host.internal:8443 ssl verify required verifyhost tls-host-instance-id ca-file /ca.pem check check-ssl\n"))
+					Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(ContainSubstring(
+						"\n  server server_default-host.internal_8080 default-host.internal:8080 check\n"))
+				})
+			})
+
+			Context("when the runtime api is enabled", func() {
+				BeforeEach(func() {
+					marshaller = haproxy.NewConfigMarshaller(logger, config.Config{
+						HealthCheck: config.HealthCheckConfig{Enabled: true, Interval: 2 * time.Second, Rise: 2, Fall: 3},
+						RuntimeAPI:  config.RuntimeAPIConfig{Enabled: true, ServerSlots: 5},
+					})
+				})
+
+				It("checks servers added to slots", func() {
+					Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(ContainSubstring("\n  server-template slot_ 1-5 0.0.0.0:1 disabled check\n"))
+				})
+			})
+		})
+
+		Context("when load balancing is configured", func() {
+			BeforeEach(func() {
+				marshaller = haproxy.NewConfigMarshaller(logger, config.Config{
+					LoadBalancing: config.LoadBalancingConfig{
+						Algorithm: config.BalanceLeastConn,
+						Ports:     map[uint16]config.BalanceAlgorithm{5432: config.BalanceSource},
+					},
+				})
+				haproxyConf = models.HAProxyConfig{
+					80: {
+						"":                          {{Address: "default-host.internal", Port: 8080}},
+						"external-host.example.com": {{Address: "sni-host.internal", Port: 9090}},
+					},
+					5432: {
+						"": {{Address: "db-host.internal", Port: 5432}},
+					},
+				}
+			})
+
+			It("balances every backend of a port with its algorithm", func() {
+				Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(Equal(`
+frontend frontend_80
+  mode tcp
+  bind :80
+  tcp-request inspect-delay 5s
+  tcp-request content accept if { req.ssl_hello_type gt 0 }
+  default_backend backend_80
+  use_backend backend_80_external-host.example.com if { req.ssl_sni external-host.example.com }
+
+backend backend_80
+  mode tcp
+  balance leastconn
+  server server_default-host.internal_8080 default-host.internal:8080
+
+backend backend_80_external-host.example.com
+  mode tcp
+  balance leastconn
+  server server_sni-host.internal_9090 sni-host.internal:9090
+
+frontend frontend_5432
+  mode tcp
+  bind :5432
+  default_backend backend_5432
+
+backend backend_5432
+  mode tcp
+  balance source
+  server server_db-host.internal_5432 db-host.internal:5432
+`))
+			})
+		})
+
+		Context("when the proxy protocol is configured", func() {
+			BeforeEach(func() {
+				marshaller = haproxy.NewConfigMarshaller(logger, config.Config{
+					ProxyProtocol: config.ProxyProtocolConfig{
+						Accept: true,
+						Send:   config.ProxyProtocolV2,
+						Routes: []config.ProxyProtocolRoute{
+							{Port: 80, SniHostname: "external-host.example.com", Version: config.ProxyProtocolV1},
+						},
+					},
+					HealthCheck: config.HealthCheckConfig{Enabled: true, Interval: 2 * time.Second, Rise: 2, Fall: 3},
+				})
+				haproxyConf = models.HAProxyConfig{
+					80: {
+						"":                          {{Address: "default-host.internal", Port: 8080}},
+						"external-host.example.com": {{Address: "sni-host.internal", Port: 9090}},
+					},
+				}
+			})
+
+			It("accepts the proxy protocol on binds and sends it to servers", func() {
+				Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(Equal(`
+frontend frontend_80
+  mode tcp
+  bind :80 accept-proxy
+  tcp-request inspect-delay 5s
+  tcp-request content accept if { req.ssl_hello_type gt 0 }
+  default_backend backend_80
+  use_backend backend_80_external-host.example.com if { req.ssl_sni external-host.example.com }
+
+backend backend_80
+  mode tcp
+  default-server inter 2000ms rise 2 fall 3 send-proxy-v2
+  server server_default-host.internal_8080 default-host.internal:8080 check
+
+backend backend_80_external-host.example.com
+  mode tcp
+  default-server inter 2000ms rise 2 fall 3 send-proxy
+  server server_sni-host.internal_9090 sni-host.internal:9090 check
+`))
+			})
+
+			Context("when a port turns it off", func() {
+				BeforeEach(func() {
+					marshaller = haproxy.NewConfigMarshaller(logger, config.Config{
+						ProxyProtocol: config.ProxyProtocolConfig{
+							Send:  config.ProxyProtocolV1,
+							Ports: map[uint16]config.ProxyProtocolVersion{80: config.ProxyProtocolNone},
+						},
+					})
+				})
+
+				It("does not send it to the servers of that port", func() {
+					output := marshaller.Marshal(haproxyConf, backendTlsCfg)
+					Expect(output).NotTo(ContainSubstring("send-proxy"))
+					Expect(output).NotTo(ContainSubstring("default-server"))
+					Expect(output).To(ContainSubstring("\n  bind :80\n"))
+				})
+			})
+		})
+
+		Context("when a timeout profile is assigned to a port", func() {
+			BeforeEach(func() {
+				marshaller = haproxy.NewConfigMarshaller(logger, config.Config{
+					Timeouts: config.TimeoutsConfig{
+						Profiles: map[string]config.TimeoutProfile{
+							"database": {
+								Connect:         5 * time.Second,
+								Client:          time.Hour,
+								Server:          time.Hour,
+								Tunnel:          2 * time.Hour,
+								ClientFin:       30 * time.Second,
+								FrontendMaxConn: 1000,
+								ServerMaxConn:   100,
+							},
+						},
+						Ports: []config.PortRangeProfile{{PortRange: config.PortRange{Start: 5000, End: 5999}, Profile: "database"}},
+					},
+				})
+				haproxyConf = models.HAProxyConfig{
+					80: {
+						"": {{Address: "default-host.internal", Port: 8080}},
+					},
+					5432: {
+						"": {{Address: "db-host.internal", Port: 5432}},
+					},
+				}
+			})
+
+			It("renders the profile into the frontends and backends of the port", func() {
+				Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(Equal(`
+frontend frontend_80
+  mode tcp
+  bind :80
+  default_backend backend_80
+
+backend backend_80
+  mode tcp
+  server server_default-host.internal_8080 default-host.internal:8080
+
+frontend frontend_5432
+  mode tcp
+  bind :5432
+  timeout client 3600000ms
+  timeout client-fin 30000ms
+  maxconn 1000
+  default_backend backend_5432
+
+backend backend_5432
+  mode tcp
+  timeout connect 5000ms
+  timeout server 3600000ms
+  timeout tunnel 7200000ms
+  default-server maxconn 100
+  server server_db-host.internal_5432 db-host.internal:5432
+`))
+			})
+		})
+
+		Context("when tls is terminated on a port", func() {
+			BeforeEach(func() {
+				marshaller = haproxy.NewConfigMarshaller(logger, config.Config{
+					TLSTermination: config.TLSTerminationConfig{CertDir: "/var/vcap/jobs/tcp_router/certs", Ports: []uint16{443}},
+				})
+				haproxyConf = models.HAProxyConfig{
+					443: {
+						"":                          {{Address: "default-host.internal", Port: 8080}},
+						"external-host.example.com": {{Address: "tls-host.internal", Port: 8888, TLSPort: 8443, InstanceID: "tls-host-instance-id"}},
+					},
+					1883: {
+						"external-host.example.com": {{Address: "mqtt-host.internal", Port: 1883}},
+					},
+				}
+				backendTlsCfg = config.BackendTLSConfig{Enabled: true, CACertificatePath: "/ca.pem"}
+			})
+
+			It("terminates tls and routes on the decrypted sni hostname", func() {
+				Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(Equal(`
+frontend frontend_443
+  mode tcp
+  bind :443 ssl crt /var/vcap/jobs/tcp_router/certs
+  default_backend backend_443
+  use_backend backend_443_external-host.example.com if { ssl_fc_sni external-host.example.com }
+
+backend backend_443
+  mode tcp
+  server server_default-host.internal_8080 default-host.internal:8080
+
+backend backend_443_external-host.example.com
+  mode tcp
+  server server_tls-host.internal_8443 tls-host.internal:8443 ssl verify required verifyhost tls-host-instance-id ca-file /ca.pem
+
+frontend frontend_1883
+  mode tcp
+  bind :1883
+  tcp-request inspect-delay 5s
+  tcp-request content accept if { req.ssl_hello_type gt 0 }
+  use_backend backend_1883_external-host.example.com if { req.ssl_sni external-host.example.com }
+
+backend backend_1883_external-host.example.com
+  mode tcp
+  server server_mqtt-host.internal_1883 mqtt-host.internal:1883
+`))
+			})
+		})
+
+		Context("when there are wildcard SNI routes", func() {
+			BeforeEach(func() {
+				haproxyConf = models.HAProxyConfig{
+					443: {
+						"":                 {{Address: "default-host.internal", Port: 8080}},
+						"*.example.com":    {{Address: "wildcard-host.internal", Port: 8081}},
+						"*.db.example.com": {{Address: "db-wildcard-host.internal", Port: 8082}},
+						"a.db.example.com": {{Address: "exact-host.internal", Port: 8083}},
+					},
+				}
+			})
+
+			It("matches wildcards by suffix after exact hostnames, most specific first", func() {
+				Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(Equal(`
+frontend frontend_443
+  mode tcp
+  bind :443
+  tcp-request inspect-delay 5s
+  tcp-request content accept if { req.ssl_hello_type gt 0 }
+  default_backend backend_443
+  use_backend backend_443_a.db.example.com if { req.ssl_sni a.db.example.com }
+  use_backend backend_443_wildcard:db.example.com if { req.ssl_sni -m end .db.example.com }
+  use_backend backend_443_wildcard:example.com if { req.ssl_sni -m end .example.com }
+
+backend backend_443
+  mode tcp
+  server server_default-host.internal_8080 default-host.internal:8080
+
+backend backend_443_a.db.example.com
+  mode tcp
+  server server_exact-host.internal_8083 exact-host.internal:8083
+
+backend backend_443_wildcard:db.example.com
+  mode tcp
+  server server_db-wildcard-host.internal_8082 db-wildcard-host.internal:8082
+
+backend backend_443_wildcard:example.com
+  mode tcp
+  server server_wildcard-host.internal_8081 wildcard-host.internal:8081
+`))
+			})
+
+			It("matches wildcards by suffix when tls is terminated", func() {
+				marshaller = haproxy.NewConfigMarshaller(logger, config.Config{
+					TLSTermination: config.TLSTerminationConfig{CertDir: "/certs", Ports: []uint16{443}},
+				})
+				Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(ContainSubstring(
+					"\n  use_backend backend_443_wildcard:db.example.com if { ssl_fc_sni -m end .db.example.com }\n"))
+			})
+		})
+
+		Context("when access control rules are configured", func() {
+			BeforeEach(func() {
+				marshaller = haproxy.NewConfigMarshaller(logger, config.Config{
+					AccessControl: config.AccessControlConfig{
+						Ports: []config.AccessRule{
+							{
+								PortRange: config.PortRange{Start: 5000, End: 5999},
+								Allow:     []string{"10.0.0.0/8", "fd00::/8"},
+								Deny:      []string{"10.1.0.0/16"},
+							},
+						},
+					},
+				})
+				haproxyConf = models.HAProxyConfig{
+					80: {
+						"": {{Address: "default-host.internal", Port: 8080}},
+					},
+					5432: {
+						"": {{Address: "db-host.internal", Port: 5432}},
+					},
+				}
+			})
+
+			It("rejects connections in the frontends of the ports they apply to", func() {
+				Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(Equal(`
+frontend frontend_80
+  mode tcp
+  bind :80
+  default_backend backend_80
+
+backend backend_80
+  mode tcp
+  server server_default-host.internal_8080 default-host.internal:8080
+
+frontend frontend_5432
+  mode tcp
+  bind :5432
+  tcp-request connection reject if { src 10.1.0.0/16 }
+  tcp-request connection reject unless { src 10.0.0.0/8 fd00::/8 }
+  default_backend backend_5432
+
+backend backend_5432
+  mode tcp
+  server server_db-host.internal_5432 db-host.internal:5432
+`))
+			})
+
+			Context("when the PROXY protocol is accepted", func() {
+				BeforeEach(func() {
+					marshaller = haproxy.NewConfigMarshaller(logger, config.Config{
+						ProxyProtocol: config.ProxyProtocolConfig{Accept: true},
+						AccessControl: config.AccessControlConfig{
+							Ports: []config.AccessRule{
+								{
+									PortRange: config.PortRange{Start: 5000, End: 5999},
+									Allow:     []string{"10.0.0.0/8"},
+									Deny:      []string{"10.1.0.0/16"},
+								},
+							},
+						},
+					})
+					delete(haproxyConf, 80)
+				})
+
+				It("matches the client address from the PROXY header", func() {
+					Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(Equal(`
+frontend frontend_5432
+  mode tcp
+  bind :5432 accept-proxy
+  tcp-request session reject if { src 10.1.0.0/16 }
+  tcp-request session reject unless { src 10.0.0.0/8 }
+  default_backend backend_5432
+
+backend backend_5432
+  mode tcp
+  server server_db-host.internal_5432 db-host.internal:5432
+`))
+				})
+			})
+		})
+
+		Context("when rate limits are configured", func() {
+			BeforeEach(func() {
+				marshaller = haproxy.NewConfigMarshaller(logger, config.Config{
+					RateLimit: config.RateLimitConfig{
+						TableSize: 1000,
+						Ports: []config.RateLimitRule{
+							{
+								PortRange:             config.PortRange{Start: 5000, End: 5999},
+								ConnectionRate:        20,
+								RatePeriod:            10 * time.Second,
+								ConcurrentConnections: 10,
+							},
+						},
+					},
+				})
+				haproxyConf = models.HAProxyConfig{
+					5432: {
+						"": {{Address: "db-host.internal", Port: 5432}},
+					},
+				}
+			})
+
+			It("tracks clients in a stick table and rejects those over the limits", func() {
+				Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(Equal(`
+frontend frontend_5432
+  mode tcp
+  bind :5432
+  stick-table type ipv6 size 1000 expire 10000ms store conn_rate(10000ms),conn_cur
+  tcp-request connection track-sc0 src
+  tcp-request content reject if { sc_conn_rate(0) gt 20 }
+  tcp-request content reject if { sc_conn_cur(0) gt 10 }
+  default_backend backend_5432
+
+backend backend_5432
+  mode tcp
+  server server_db-host.internal_5432 db-host.internal:5432
+`))
+			})
+
+			Context("when the PROXY protocol is accepted", func() {
+				BeforeEach(func() {
+					marshaller = haproxy.NewConfigMarshaller(logger, config.Config{
+						ProxyProtocol: config.ProxyProtocolConfig{Accept: true},
+						RateLimit: config.RateLimitConfig{
+							TableSize: 1000,
+							Ports: []config.RateLimitRule{
+								{
+									PortRange:      config.PortRange{Start: 5000, End: 5999},
+									ConnectionRate: 20,
+									RatePeriod:     10 * time.Second,
+								},
+							},
+						},
+					})
+				})
+
+				It("tracks clients by the address from the PROXY header", func() {
+					Expect(marshaller.Marshal(haproxyConf, backendTlsCfg)).To(Equal(`
+frontend frontend_5432
+  mode tcp
+  bind :5432 accept-proxy
+  stick-table type ipv6 size 1000 expire 10000ms store conn_rate(10000ms),conn_cur
+  tcp-request session track-sc0 src
+  tcp-request content reject if { sc_conn_rate(0) gt 20 }
+  default_backend backend_5432
+
+backend backend_5432
+  mode tcp
+  server server_db-host.internal_5432 db-host.internal:5432
+`))
+				})
+			})
+		})
 	})
 })
@@ -0,0 +1,44 @@
+package haproxy
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"code.cloudfoundry.org/lager/v3"
+)
+
+//go:generate counterfeiter -o fakes/fake_config_validator.go . ConfigValidator
+type ConfigValidator interface {
+	Validate(configFilePath string) error
+}
+
+// CommandValidator checks a config file by running a command, such as
+// `haproxy -c -f`, with the path of the file appended to its arguments.
+type CommandValidator struct {
+	command []string
+	logger  lager.Logger
+}
+
+func CreateCommandValidator(command string, logger lager.Logger) *CommandValidator {
+	return &CommandValidator{
+		command: strings.Fields(command),
+		logger:  logger,
+	}
+}
+
+func (v *CommandValidator) Validate(configFilePath string) error {
+	if len(v.command) == 0 {
+		return fmt.Errorf("no config validator command configured")
+	}
+
+	args := append(v.command[1:len(v.command):len(v.command)], configFilePath)
+	validatorCmd := exec.Command(v.command[0], args...)
+
+	output, err := validatorCmd.CombinedOutput()
+	v.logger.Info("running-config-validator", lager.Data{"command": strings.Join(validatorCmd.Args, " "), "output": string(output), "error": err})
+	if err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
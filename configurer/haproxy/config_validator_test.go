@@ -0,0 +1,54 @@
+package haproxy_test
+
+import (
+	. "code.cloudfoundry.org/cf-tcp-router/configurer/haproxy"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("CommandValidator", func() {
+	var (
+		validator *CommandValidator
+		logger    lager.Logger
+	)
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("config-validator-test")
+	})
+	Describe("Validate", func() {
+		Context("when the command accepts the config", func() {
+			BeforeEach(func() {
+				validator = CreateCommandValidator("test -s", logger)
+			})
+			It("appends the config file path to the command", func() {
+				err := validator.Validate("fixtures/haproxy.cfg.template")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(logger).Should(gbytes.Say(`"command":"test -s fixtures/haproxy.cfg.template"`))
+			})
+		})
+
+		Context("when the command rejects the config", func() {
+			BeforeEach(func() {
+				validator = CreateCommandValidator("fixtures/badscript", logger)
+			})
+			It("returns an error including the command output", func() {
+				err := validator.Validate("fixtures/haproxy.cfg.template")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("exit status 1"))
+				Expect(err.Error()).To(ContainSubstring("negative test"))
+			})
+		})
+
+		Context("when no command is configured", func() {
+			BeforeEach(func() {
+				validator = CreateCommandValidator("", logger)
+			})
+			It("returns an error", func() {
+				Expect(validator.Validate("fixtures/haproxy.cfg.template")).ToNot(Succeed())
+			})
+		})
+	})
+})
@@ -0,0 +1,102 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/cf-tcp-router/configurer/haproxy"
+)
+
+type FakeReadiness struct {
+	SyncedStub        func() bool
+	syncedMutex       sync.RWMutex
+	syncedArgsForCall []struct {
+	}
+	syncedReturns struct {
+		result1 bool
+	}
+	syncedReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeReadiness) Synced() bool {
+	fake.syncedMutex.Lock()
+	ret, specificReturn := fake.syncedReturnsOnCall[len(fake.syncedArgsForCall)]
+	fake.syncedArgsForCall = append(fake.syncedArgsForCall, struct {
+	}{})
+	stub := fake.SyncedStub
+	fakeReturns := fake.syncedReturns
+	fake.recordInvocation("Synced", []interface{}{})
+	fake.syncedMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeReadiness) SyncedCallCount() int {
+	fake.syncedMutex.RLock()
+	defer fake.syncedMutex.RUnlock()
+	return len(fake.syncedArgsForCall)
+}
+
+func (fake *FakeReadiness) SyncedCalls(stub func() bool) {
+	fake.syncedMutex.Lock()
+	defer fake.syncedMutex.Unlock()
+	fake.SyncedStub = stub
+}
+
+func (fake *FakeReadiness) SyncedReturns(result1 bool) {
+	fake.syncedMutex.Lock()
+	defer fake.syncedMutex.Unlock()
+	fake.SyncedStub = nil
+	fake.syncedReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeReadiness) SyncedReturnsOnCall(i int, result1 bool) {
+	fake.syncedMutex.Lock()
+	defer fake.syncedMutex.Unlock()
+	fake.SyncedStub = nil
+	if fake.syncedReturnsOnCall == nil {
+		fake.syncedReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.syncedReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeReadiness) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.syncedMutex.RLock()
+	defer fake.syncedMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeReadiness) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ haproxy.Readiness = new(FakeReadiness)
@@ -0,0 +1,195 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/cf-tcp-router/configurer/haproxy"
+)
+
+type FakeRuntimeAPI struct {
+	SetServerAddressStub        func(string, string, string, uint16) error
+	setServerAddressMutex       sync.RWMutex
+	setServerAddressArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 uint16
+	}
+	setServerAddressReturns struct {
+		result1 error
+	}
+	setServerAddressReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SetServerStateStub        func(string, string, haproxy.ServerState) error
+	setServerStateMutex       sync.RWMutex
+	setServerStateArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 haproxy.ServerState
+	}
+	setServerStateReturns struct {
+		result1 error
+	}
+	setServerStateReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeRuntimeAPI) SetServerAddress(arg1 string, arg2 string, arg3 string, arg4 uint16) error {
+	fake.setServerAddressMutex.Lock()
+	ret, specificReturn := fake.setServerAddressReturnsOnCall[len(fake.setServerAddressArgsForCall)]
+	fake.setServerAddressArgsForCall = append(fake.setServerAddressArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 uint16
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.SetServerAddressStub
+	fakeReturns := fake.setServerAddressReturns
+	fake.recordInvocation("SetServerAddress", []interface{}{arg1, arg2, arg3, arg4})
+	fake.setServerAddressMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRuntimeAPI) SetServerAddressCallCount() int {
+	fake.setServerAddressMutex.RLock()
+	defer fake.setServerAddressMutex.RUnlock()
+	return len(fake.setServerAddressArgsForCall)
+}
+
+func (fake *FakeRuntimeAPI) SetServerAddressCalls(stub func(string, string, string, uint16) error) {
+	fake.setServerAddressMutex.Lock()
+	defer fake.setServerAddressMutex.Unlock()
+	fake.SetServerAddressStub = stub
+}
+
+func (fake *FakeRuntimeAPI) SetServerAddressArgsForCall(i int) (string, string, string, uint16) {
+	fake.setServerAddressMutex.RLock()
+	defer fake.setServerAddressMutex.RUnlock()
+	argsForCall := fake.setServerAddressArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeRuntimeAPI) SetServerAddressReturns(result1 error) {
+	fake.setServerAddressMutex.Lock()
+	defer fake.setServerAddressMutex.Unlock()
+	fake.SetServerAddressStub = nil
+	fake.setServerAddressReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRuntimeAPI) SetServerAddressReturnsOnCall(i int, result1 error) {
+	fake.setServerAddressMutex.Lock()
+	defer fake.setServerAddressMutex.Unlock()
+	fake.SetServerAddressStub = nil
+	if fake.setServerAddressReturnsOnCall == nil {
+		fake.setServerAddressReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setServerAddressReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRuntimeAPI) SetServerState(arg1 string, arg2 string, arg3 haproxy.ServerState) error {
+	fake.setServerStateMutex.Lock()
+	ret, specificReturn := fake.setServerStateReturnsOnCall[len(fake.setServerStateArgsForCall)]
+	fake.setServerStateArgsForCall = append(fake.setServerStateArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 haproxy.ServerState
+	}{arg1, arg2, arg3})
+	stub := fake.SetServerStateStub
+	fakeReturns := fake.setServerStateReturns
+	fake.recordInvocation("SetServerState", []interface{}{arg1, arg2, arg3})
+	fake.setServerStateMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRuntimeAPI) SetServerStateCallCount() int {
+	fake.setServerStateMutex.RLock()
+	defer fake.setServerStateMutex.RUnlock()
+	return len(fake.setServerStateArgsForCall)
+}
+
+func (fake *FakeRuntimeAPI) SetServerStateCalls(stub func(string, string, haproxy.ServerState) error) {
+	fake.setServerStateMutex.Lock()
+	defer fake.setServerStateMutex.Unlock()
+	fake.SetServerStateStub = stub
+}
+
+func (fake *FakeRuntimeAPI) SetServerStateArgsForCall(i int) (string, string, haproxy.ServerState) {
+	fake.setServerStateMutex.RLock()
+	defer fake.setServerStateMutex.RUnlock()
+	argsForCall := fake.setServerStateArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeRuntimeAPI) SetServerStateReturns(result1 error) {
+	fake.setServerStateMutex.Lock()
+	defer fake.setServerStateMutex.Unlock()
+	fake.SetServerStateStub = nil
+	fake.setServerStateReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRuntimeAPI) SetServerStateReturnsOnCall(i int, result1 error) {
+	fake.setServerStateMutex.Lock()
+	defer fake.setServerStateMutex.Unlock()
+	fake.SetServerStateStub = nil
+	if fake.setServerStateReturnsOnCall == nil {
+		fake.setServerStateReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setServerStateReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRuntimeAPI) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.setServerAddressMutex.RLock()
+	defer fake.setServerAddressMutex.RUnlock()
+	fake.setServerStateMutex.RLock()
+	defer fake.setServerStateMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeRuntimeAPI) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ haproxy.RuntimeAPI = new(FakeRuntimeAPI)
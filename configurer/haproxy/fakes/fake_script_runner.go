@@ -8,10 +8,11 @@ import (
 )
 
 type FakeScriptRunner struct {
-	RunStub        func(bool) error
+	RunStub        func(bool, bool) error
 	runMutex       sync.RWMutex
 	runArgsForCall []struct {
 		arg1 bool
+		arg2 bool
 	}
 	runReturns struct {
 		result1 error
@@ -23,18 +24,19 @@ type FakeScriptRunner struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeScriptRunner) Run(arg1 bool) error {
+func (fake *FakeScriptRunner) Run(arg1 bool, arg2 bool) error {
 	fake.runMutex.Lock()
 	ret, specificReturn := fake.runReturnsOnCall[len(fake.runArgsForCall)]
 	fake.runArgsForCall = append(fake.runArgsForCall, struct {
 		arg1 bool
-	}{arg1})
+		arg2 bool
+	}{arg1, arg2})
 	stub := fake.RunStub
 	fakeReturns := fake.runReturns
-	fake.recordInvocation("Run", []interface{}{arg1})
+	fake.recordInvocation("Run", []interface{}{arg1, arg2})
 	fake.runMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
@@ -48,17 +50,17 @@ func (fake *FakeScriptRunner) RunCallCount() int {
 	return len(fake.runArgsForCall)
 }
 
-func (fake *FakeScriptRunner) RunCalls(stub func(bool) error) {
+func (fake *FakeScriptRunner) RunCalls(stub func(bool, bool) error) {
 	fake.runMutex.Lock()
 	defer fake.runMutex.Unlock()
 	fake.RunStub = stub
 }
 
-func (fake *FakeScriptRunner) RunArgsForCall(i int) bool {
+func (fake *FakeScriptRunner) RunArgsForCall(i int) (bool, bool) {
 	fake.runMutex.RLock()
 	defer fake.runMutex.RUnlock()
 	argsForCall := fake.runArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeScriptRunner) RunReturns(result1 error) {
@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"code.cloudfoundry.org/cf-tcp-router/config"
+	"code.cloudfoundry.org/cf-tcp-router/metrics_reporter"
 	"code.cloudfoundry.org/cf-tcp-router/models"
 	"code.cloudfoundry.org/cf-tcp-router/monitor"
 	"code.cloudfoundry.org/cf-tcp-router/utils"
@@ -18,6 +19,20 @@ const (
 	ErrRouterCAFileNotFound     = "CA file not found"
 )
 
+var (
+	reloads         = metrics_reporter.Counter("Reloads")
+	rejectedConfigs = metrics_reporter.Counter("RejectedConfigs")
+	failedReloads   = metrics_reporter.Counter("FailedReloads")
+)
+
+// Readiness reports whether the routing table holds the routes of a full
+// sync, so that applying it makes the router ready.
+//
+//go:generate counterfeiter -o fakes/fake_readiness.go . Readiness
+type Readiness interface {
+	Synced() bool
+}
+
 type Configurer struct {
 	logger             lager.Logger
 	configMarshaller   ConfigMarshaller
@@ -27,21 +42,32 @@ type Configurer struct {
 	backendTlsCfg      config.BackendTLSConfig
 	monitor            monitor.Monitor
 	scriptRunner       ScriptRunner
+	configValidator    ConfigValidator
+	runtimeAPI         RuntimeAPI
+	serverSlots        int
+	readiness          Readiness
+
+	// runtimeState is nil until HAProxy has been reloaded with a config
+	// written by this Configurer, and whenever a reload fails
+	runtimeState               runtimeState
+	lastForceHealthCheckToFail bool
+	lastReady                  bool
 }
 
-func NewHaProxyConfigurer(logger lager.Logger, configMarshaller ConfigMarshaller, baseConfigFilePath string, configFilePath string, monitor monitor.Monitor, scriptRunner ScriptRunner, backendTlsCfg config.BackendTLSConfig) (*Configurer, error) {
+func NewHaProxyConfigurer(logger lager.Logger, configMarshaller ConfigMarshaller, baseConfigFilePath string, configFilePath string, monitor monitor.Monitor, scriptRunner ScriptRunner, configValidator ConfigValidator, runtimeAPI RuntimeAPI, cfg config.Config) (*Configurer, error) {
+	backendTlsCfg := cfg.BackendTLS
 	if !utils.FileExists(baseConfigFilePath) {
 		return nil, fmt.Errorf("%s: [%s]", ErrRouterConfigFileNotFound, baseConfigFilePath)
 	}
 	if !utils.FileExists(configFilePath) {
 		return nil, fmt.Errorf("%s: [%s]", ErrRouterConfigFileNotFound, configFilePath)
 	}
-	if backendTlsCfg.CACertificatePath != "" && !utils.FileExists(backendTlsCfg.CACertificatePath) {
-		return nil, fmt.Errorf("%s: [%s]", ErrRouterCAFileNotFound, backendTlsCfg.CACertificatePath)
+	if err := validateBackendTLSFiles(backendTlsCfg); err != nil {
+		return nil, err
 	}
-
-	if backendTlsCfg.ClientCertAndKeyPath != "" && !utils.FileExists(backendTlsCfg.ClientCertAndKeyPath) {
-		return nil, fmt.Errorf("%s: [%s]", ErrRouterCAFileNotFound, backendTlsCfg.ClientCertAndKeyPath)
+	backendTlsCfg, err := installBackendTLSFiles(backendTlsCfg, configFilePath)
+	if err != nil {
+		return nil, err
 	}
 
 	return &Configurer{
@@ -53,9 +79,112 @@ func NewHaProxyConfigurer(logger lager.Logger, configMarshaller ConfigMarshaller
 		backendTlsCfg:      backendTlsCfg,
 		monitor:            monitor,
 		scriptRunner:       scriptRunner,
+		configValidator:    configValidator,
+		runtimeAPI:         runtimeAPI,
+		serverSlots:        cfg.RuntimeAPI.ServerSlots,
 	}, nil
 }
 
+func validateBackendTLSFiles(backendTlsCfg config.BackendTLSConfig) error {
+	if backendTlsCfg.CACertificatePath != "" && !utils.FileExists(backendTlsCfg.CACertificatePath) {
+		return fmt.Errorf("%s: [%s]", ErrRouterCAFileNotFound, backendTlsCfg.CACertificatePath)
+	}
+
+	if backendTlsCfg.ClientCertAndKeyPath != "" && !utils.FileExists(backendTlsCfg.ClientCertAndKeyPath) {
+		return fmt.Errorf("%s: [%s]", ErrRouterCAFileNotFound, backendTlsCfg.ClientCertAndKeyPath)
+	}
+	return nil
+}
+
+// installBackendTLSFiles copies the backend TLS files next to the HAProxy
+// config and returns settings that point at the copies. HAProxy reads these
+// files again on every reload, so files that are rotated in place must not
+// reach it before they have been validated. Settings with backend TLS
+// disabled are returned as they are.
+func installBackendTLSFiles(backendTlsCfg config.BackendTLSConfig, configFilePath string) (config.BackendTLSConfig, error) {
+	if !backendTlsCfg.Enabled {
+		return backendTlsCfg, nil
+	}
+
+	installed := backendTlsCfg
+	if installed.CACertificatePath != "" {
+		installed.CACertificatePath = fmt.Sprintf("%s.ca.pem", configFilePath)
+	}
+	if installed.ClientCertAndKeyPath != "" {
+		installed.ClientCertAndKeyPath = fmt.Sprintf("%s.client.pem", configFilePath)
+	}
+
+	// Validate copies of the files, since the originals may change again
+	staged := installed
+	if staged.CACertificatePath != "" {
+		staged.CACertificatePath = fmt.Sprintf("%s.tmp", installed.CACertificatePath)
+		defer os.Remove(staged.CACertificatePath) // #nosec G104 - the copy is gone once it was installed
+		if err := copyPrivateFile(backendTlsCfg.CACertificatePath, staged.CACertificatePath); err != nil {
+			return config.BackendTLSConfig{}, err
+		}
+	}
+	if staged.ClientCertAndKeyPath != "" {
+		staged.ClientCertAndKeyPath = fmt.Sprintf("%s.tmp", installed.ClientCertAndKeyPath)
+		defer os.Remove(staged.ClientCertAndKeyPath) // #nosec G104 - the copy is gone once it was installed
+		if err := copyPrivateFile(backendTlsCfg.ClientCertAndKeyPath, staged.ClientCertAndKeyPath); err != nil {
+			return config.BackendTLSConfig{}, err
+		}
+	}
+	if err := staged.Validate(); err != nil {
+		return config.BackendTLSConfig{}, err
+	}
+
+	if staged.CACertificatePath != "" {
+		if err := os.Rename(staged.CACertificatePath, installed.CACertificatePath); err != nil {
+			return config.BackendTLSConfig{}, err
+		}
+	}
+	if staged.ClientCertAndKeyPath != "" {
+		if err := os.Rename(staged.ClientCertAndKeyPath, installed.ClientCertAndKeyPath); err != nil {
+			return config.BackendTLSConfig{}, err
+		}
+	}
+	return installed, nil
+}
+
+// copyPrivateFile copies a file that may contain a private key, so that the
+// copy is only readable by the router.
+func copyPrivateFile(src, dest string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dest, data, 0600)
+}
+
+// SetBackendTLS replaces the backend TLS settings used by the next Configure.
+// The next Configure always reloads HAProxy, since servers already loaded by
+// HAProxy keep the settings they were started with. Certificates that fail
+// validation are rejected, and HAProxy keeps using the ones installed before.
+func (h *Configurer) SetBackendTLS(backendTlsCfg config.BackendTLSConfig) error {
+	if err := validateBackendTLSFiles(backendTlsCfg); err != nil {
+		return err
+	}
+
+	h.configFileLock.Lock()
+	defer h.configFileLock.Unlock()
+	backendTlsCfg, err := installBackendTLSFiles(backendTlsCfg, h.configFilePath)
+	if err != nil {
+		return err
+	}
+	h.backendTlsCfg = backendTlsCfg
+	h.runtimeState = nil
+	return nil
+}
+
+// SetReadiness makes the reload script report whether the router is ready.
+// Without it the router is always reported as ready.
+func (h *Configurer) SetReadiness(readiness Readiness) {
+	h.configFileLock.Lock()
+	defer h.configFileLock.Unlock()
+	h.readiness = readiness
+}
+
 func (h *Configurer) Configure(routingTable models.RoutingTable, forceHealthCheckToFail bool) error {
 	h.monitor.StopWatching()
 	h.configFileLock.Lock()
@@ -91,22 +220,65 @@ func (h *Configurer) Configure(routingTable models.RoutingTable, forceHealthChec
 	h.logger.Info("writing-config", lager.Data{"num-bytes": buff.Len()})
 	err = h.writeToConfig(buff.Bytes())
 	if err != nil {
+		// HAProxy keeps running with the previous config, so keep watching it.
+		h.monitor.StartWatching()
 		return err
 	}
 
 	if h.scriptRunner != nil {
+		ready := h.readiness == nil || h.readiness.Synced()
+		if h.applyRuntimeChanges(haproxyConf, forceHealthCheckToFail, ready) {
+			h.monitor.StartWatching()
+			return nil
+		}
+
 		h.logger.Info("reloading-haproxy")
 
-		err = h.scriptRunner.Run(forceHealthCheckToFail)
+		err = h.scriptRunner.Run(forceHealthCheckToFail, ready)
 		if err != nil {
+			h.runtimeState = nil
 			h.logger.Error("failed-to-reload-haproxy", err)
+			failedReloads.Add(1)
+			h.restoreConfigBackup()
+			h.monitor.StartWatching()
 			return err
 		}
+		reloads.Add(1)
 		h.monitor.StartWatching()
+
+		if h.runtimeAPI != nil {
+			h.runtimeState = newRuntimeState(haproxyConf, h.backendTlsCfg, h.serverSlots)
+			h.lastForceHealthCheckToFail = forceHealthCheckToFail
+			h.lastReady = ready
+		}
 	}
 	return nil
 }
 
+// applyRuntimeChanges updates the servers of the running HAProxy process
+// through the runtime API. It returns false when HAProxy needs to be
+// reloaded instead, e.g. because frontends or backends were added or removed.
+func (h *Configurer) applyRuntimeChanges(haproxyConf models.HAProxyConfig, forceHealthCheckToFail bool, ready bool) bool {
+	if h.runtimeAPI == nil || h.runtimeState == nil {
+		return false
+	}
+	// The reload script is responsible for toggling the health check
+	if forceHealthCheckToFail != h.lastForceHealthCheckToFail || ready != h.lastReady {
+		return false
+	}
+
+	nextState, changes, err := h.runtimeState.apply(haproxyConf, h.backendTlsCfg, h.runtimeAPI)
+	if err != nil {
+		h.logger.Info("falling-back-to-reload", lager.Data{"reason": err.Error(), "applied-changes": changes})
+		h.runtimeState = nil
+		return false
+	}
+
+	h.logger.Info("applied-runtime-changes", lager.Data{"changes": changes})
+	h.runtimeState = nextState
+	return true
+}
+
 func (h *Configurer) createConfigBackup() error {
 	h.logger.Debug("reading-config-file", lager.Data{"config-file": h.configFilePath})
 	cfgContent, err := os.ReadFile(h.configFilePath)
@@ -131,6 +303,16 @@ func (h *Configurer) writeToConfig(cfgContent []byte) error {
 		return err
 	}
 
+	if h.configValidator != nil {
+		err = h.configValidator.Validate(tmpConfigFileName)
+		if err != nil {
+			h.logger.Error("rejected-config", err, lager.Data{"temp-config-file": tmpConfigFileName})
+			rejectedConfigs.Add(1)
+			_ = os.Remove(tmpConfigFileName)
+			return err
+		}
+	}
+
 	err = os.Rename(tmpConfigFileName, h.configFilePath)
 	if err != nil {
 		h.logger.Error(
@@ -141,3 +323,19 @@ func (h *Configurer) writeToConfig(cfgContent []byte) error {
 	}
 	return nil
 }
+
+// restoreConfigBackup puts back the config file that was in place before the
+// failed reload, so that HAProxy can still start from it.
+func (h *Configurer) restoreConfigBackup() {
+	backupConfigFileName := fmt.Sprintf("%s.bak", h.configFilePath)
+	tmpConfigFileName := fmt.Sprintf("%s.tmp", h.configFilePath)
+	err := utils.CopyFile(backupConfigFileName, tmpConfigFileName)
+	if err == nil {
+		err = os.Rename(tmpConfigFileName, h.configFilePath)
+	}
+	if err != nil {
+		h.logger.Error("failed-to-restore-config-backup", err, lager.Data{"backup-config-file": backupConfigFileName})
+		return
+	}
+	h.logger.Info("restored-config-backup", lager.Data{"backup-config-file": backupConfigFileName})
+}
@@ -1,6 +1,7 @@
 package haproxy_test
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -12,8 +13,11 @@ import (
 	monitorFakes "code.cloudfoundry.org/cf-tcp-router/monitor/fakes"
 	"code.cloudfoundry.org/cf-tcp-router/testutil"
 	"code.cloudfoundry.org/cf-tcp-router/utils"
+	"github.com/cloudfoundry/dropsonde/metric_sender/fake"
+	"github.com/cloudfoundry/dropsonde/metrics"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 )
 
 var _ = Describe("HaproxyConfigurer", func() {
@@ -39,7 +43,7 @@ var _ = Describe("HaproxyConfigurer", func() {
 
 		Context("when empty base configuration file is passed", func() {
 			It("returns a ErrRouterConfigFileNotFound error", func() {
-				_, err := haproxy.NewHaProxyConfigurer(logger, haproxy.NewConfigMarshaller(logger), "", haproxyConfigFile, fakeMonitor, nil, backendTlsCfg)
+				_, err := haproxy.NewHaProxyConfigurer(logger, haproxy.NewConfigMarshaller(logger, config.Config{}), "", haproxyConfigFile, fakeMonitor, nil, nil, nil, config.Config{BackendTLS: backendTlsCfg})
 				Expect(err).Should(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring(haproxy.ErrRouterConfigFileNotFound))
 			})
@@ -47,7 +51,7 @@ var _ = Describe("HaproxyConfigurer", func() {
 
 		Context("when empty configuration file is passed", func() {
 			It("returns a ErrRouterConfigFileNotFound error", func() {
-				_, err := haproxy.NewHaProxyConfigurer(logger, haproxy.NewConfigMarshaller(logger), haproxyConfigTemplate, "", fakeMonitor, nil, backendTlsCfg)
+				_, err := haproxy.NewHaProxyConfigurer(logger, haproxy.NewConfigMarshaller(logger, config.Config{}), haproxyConfigTemplate, "", fakeMonitor, nil, nil, nil, config.Config{BackendTLS: backendTlsCfg})
 				Expect(err).Should(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring(haproxy.ErrRouterConfigFileNotFound))
 			})
@@ -55,7 +59,7 @@ var _ = Describe("HaproxyConfigurer", func() {
 
 		Context("when an empty CA file path is passed", func() {
 			It("does not return a ErrRouterCAFileNotFound error", func() {
-				_, err := haproxy.NewHaProxyConfigurer(logger, haproxy.NewConfigMarshaller(logger), haproxyConfigTemplate, haproxyConfigFile, fakeMonitor, nil, config.BackendTLSConfig{})
+				_, err := haproxy.NewHaProxyConfigurer(logger, haproxy.NewConfigMarshaller(logger, config.Config{}), haproxyConfigTemplate, haproxyConfigFile, fakeMonitor, nil, nil, nil, config.Config{BackendTLS: config.BackendTLSConfig{}})
 				Expect(err).ShouldNot(HaveOccurred())
 			})
 
@@ -63,7 +67,7 @@ var _ = Describe("HaproxyConfigurer", func() {
 
 		Context("when base configuration file does not exist", func() {
 			It("returns a ErrRouterConfigFileNotFound error", func() {
-				_, err := haproxy.NewHaProxyConfigurer(logger, haproxy.NewConfigMarshaller(logger), "file/path/does/not/exist", haproxyConfigFile, fakeMonitor, nil, backendTlsCfg)
+				_, err := haproxy.NewHaProxyConfigurer(logger, haproxy.NewConfigMarshaller(logger, config.Config{}), "file/path/does/not/exist", haproxyConfigFile, fakeMonitor, nil, nil, nil, config.Config{BackendTLS: backendTlsCfg})
 				Expect(err).Should(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring(haproxy.ErrRouterConfigFileNotFound))
 			})
@@ -71,7 +75,7 @@ var _ = Describe("HaproxyConfigurer", func() {
 
 		Context("when the CA file path does not exist", func() {
 			It("returns a ErrRouterCAFileNotFound error", func() {
-				_, err := haproxy.NewHaProxyConfigurer(logger, haproxy.NewConfigMarshaller(logger), haproxyConfigTemplate, haproxyConfigFile, fakeMonitor, nil, config.BackendTLSConfig{CACertificatePath: "file/path/does/not/exist"})
+				_, err := haproxy.NewHaProxyConfigurer(logger, haproxy.NewConfigMarshaller(logger, config.Config{}), haproxyConfigTemplate, haproxyConfigFile, fakeMonitor, nil, nil, nil, config.Config{BackendTLS: config.BackendTLSConfig{CACertificatePath: "file/path/does/not/exist"}})
 				Expect(err).Should(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring(haproxy.ErrRouterCAFileNotFound))
 			})
@@ -80,7 +84,7 @@ var _ = Describe("HaproxyConfigurer", func() {
 
 		Context("when configuration file does not exist", func() {
 			It("returns a ErrRouterConfigFileNotFound error", func() {
-				_, err := haproxy.NewHaProxyConfigurer(logger, haproxy.NewConfigMarshaller(logger), haproxyConfigTemplate, "file/path/does/not/exist", fakeMonitor, nil, backendTlsCfg)
+				_, err := haproxy.NewHaProxyConfigurer(logger, haproxy.NewConfigMarshaller(logger, config.Config{}), haproxyConfigTemplate, "file/path/does/not/exist", fakeMonitor, nil, nil, nil, config.Config{BackendTLS: backendTlsCfg})
 				Expect(err).Should(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring(haproxy.ErrRouterConfigFileNotFound))
 			})
@@ -113,7 +117,7 @@ var _ = Describe("HaproxyConfigurer", func() {
 
 				fakeMarshaller = new(fakes.FakeConfigMarshaller)
 				fakeScriptRunner = new(fakes.FakeScriptRunner)
-				haproxyConfigurer, err = haproxy.NewHaProxyConfigurer(logger, fakeMarshaller, haproxyConfigTemplate, generatedHaproxyCfgFile, fakeMonitor, fakeScriptRunner, backendTlsCfg)
+				haproxyConfigurer, err = haproxy.NewHaProxyConfigurer(logger, fakeMarshaller, haproxyConfigTemplate, generatedHaproxyCfgFile, fakeMonitor, fakeScriptRunner, nil, nil, config.Config{BackendTLS: backendTlsCfg})
 				Expect(err).ShouldNot(HaveOccurred())
 
 				fakeMarshaller.MarshalCalls(func(haproxyConf models.HAProxyConfig, backendTlsCfg config.BackendTLSConfig) string {
@@ -147,6 +151,69 @@ var _ = Describe("HaproxyConfigurer", func() {
 				})
 			})
 
+			Context("when the backend tls settings are replaced", func() {
+				var (
+					caFile          string
+					installedCAFile string
+				)
+
+				BeforeEach(func() {
+					caFile, _ = tlshelpers.GenerateCa()
+					installedCAFile = fmt.Sprintf("%s.ca.pem", generatedHaproxyCfgFile)
+				})
+
+				AfterEach(func() {
+					_ = os.Remove(installedCAFile)
+				})
+
+				It("uses a copy of the certificates for the next configuration", func() {
+					Expect(haproxyConfigurer.SetBackendTLS(config.BackendTLSConfig{Enabled: true, CACertificatePath: caFile})).To(Succeed())
+					Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+
+					_, usedBackendTlsCfg := fakeMarshaller.MarshalArgsForCall(0)
+					Expect(usedBackendTlsCfg).To(Equal(config.BackendTLSConfig{Enabled: true, CACertificatePath: installedCAFile}))
+
+					original, err := os.ReadFile(caFile)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(os.ReadFile(installedCAFile)).To(Equal(original))
+				})
+
+				It("keeps the copy of the previous certificates when the new ones are invalid", func() {
+					Expect(haproxyConfigurer.SetBackendTLS(config.BackendTLSConfig{Enabled: true, CACertificatePath: caFile})).To(Succeed())
+					original, err := os.ReadFile(caFile)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(os.WriteFile(caFile, []byte("not a certificate"), 0600)).To(Succeed())
+					err = haproxyConfigurer.SetBackendTLS(config.BackendTLSConfig{Enabled: true, CACertificatePath: caFile})
+					Expect(err).To(MatchError(ContainSubstring("Invalid PEM block")))
+
+					Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+					_, usedBackendTlsCfg := fakeMarshaller.MarshalArgsForCall(0)
+					Expect(usedBackendTlsCfg.CACertificatePath).To(Equal(installedCAFile))
+					Expect(os.ReadFile(installedCAFile)).To(Equal(original))
+					Expect(fmt.Sprintf("%s.tmp", installedCAFile)).NotTo(BeAnExistingFile())
+				})
+
+				It("rejects settings pointing at missing files", func() {
+					err := haproxyConfigurer.SetBackendTLS(config.BackendTLSConfig{Enabled: true, CACertificatePath: "file/path/does/not/exist"})
+					Expect(err).To(MatchError(ContainSubstring(haproxy.ErrRouterCAFileNotFound)))
+
+					Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+					_, usedBackendTlsCfg := fakeMarshaller.MarshalArgsForCall(0)
+					Expect(usedBackendTlsCfg).To(Equal(backendTlsCfg))
+				})
+			})
+
+			Context("when HAProxy is reloaded", func() {
+				It("counts the reload", func() {
+					sender := fake.NewFakeMetricSender()
+					metrics.Initialize(sender, nil)
+
+					Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+					Expect(sender.GetCounter("Reloads")).To(Equal(uint64(1)))
+				})
+			})
+
 			Context("when Configure is called twice", func() {
 				It("overwrites the file every time (does not accumulate marshalled contents)", func() {
 					err = haproxyConfigurer.Configure(routingTable, false)
@@ -173,7 +240,7 @@ var _ = Describe("HaproxyConfigurer", func() {
 				It("calls scriptRunner.Run() with true", func() {
 					err = haproxyConfigurer.Configure(routingTable, true)
 					Expect(err).ToNot(HaveOccurred())
-					forceHealthCheckToFail := fakeScriptRunner.RunArgsForCall(0)
+					forceHealthCheckToFail, _ := fakeScriptRunner.RunArgsForCall(0)
 					Expect(forceHealthCheckToFail).To(BeTrue())
 
 				})
@@ -182,11 +249,301 @@ var _ = Describe("HaproxyConfigurer", func() {
 				It("calls scriptRunner.Run() with false", func() {
 					err = haproxyConfigurer.Configure(routingTable, false)
 					Expect(err).ToNot(HaveOccurred())
-					forceHealthCheckToFail := fakeScriptRunner.RunArgsForCall(0)
+					forceHealthCheckToFail, _ := fakeScriptRunner.RunArgsForCall(0)
 					Expect(forceHealthCheckToFail).To(BeFalse())
 
 				})
 			})
+
+			Context("when no readiness is set", func() {
+				It("reports the router as ready to the reload script", func() {
+					Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+					_, ready := fakeScriptRunner.RunArgsForCall(0)
+					Expect(ready).To(BeTrue())
+				})
+			})
+
+			Context("when a readiness is set", func() {
+				var fakeReadiness *fakes.FakeReadiness
+
+				JustBeforeEach(func() {
+					fakeReadiness = new(fakes.FakeReadiness)
+					haproxyConfigurer.SetReadiness(fakeReadiness)
+				})
+
+				It("reports whether the router is ready to the reload script", func() {
+					Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+					_, ready := fakeScriptRunner.RunArgsForCall(0)
+					Expect(ready).To(BeFalse())
+
+					fakeReadiness.SyncedReturns(true)
+					Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+					_, ready = fakeScriptRunner.RunArgsForCall(1)
+					Expect(ready).To(BeTrue())
+				})
+			})
+
+			Context("when a config validator is configured", func() {
+				var (
+					fakeConfigValidator *fakes.FakeConfigValidator
+					sender              *fake.FakeMetricSender
+				)
+
+				BeforeEach(func() {
+					sender = fake.NewFakeMetricSender()
+					metrics.Initialize(sender, nil)
+
+					fakeConfigValidator = new(fakes.FakeConfigValidator)
+					haproxyConfigurer, err = haproxy.NewHaProxyConfigurer(logger, fakeMarshaller, haproxyConfigTemplate, generatedHaproxyCfgFile, fakeMonitor, fakeScriptRunner, fakeConfigValidator, nil, config.Config{BackendTLS: backendTlsCfg})
+					Expect(err).ShouldNot(HaveOccurred())
+				})
+
+				It("validates the temp config file before it replaces the config file", func() {
+					fakeConfigValidator.ValidateCalls(func(configFilePath string) error {
+						Expect(configFilePath).To(Equal(generatedHaproxyCfgFile + ".tmp"))
+						Expect(os.ReadFile(configFilePath)).To(ContainSubstring(marshallerContent))
+						Expect(os.ReadFile(generatedHaproxyCfgFile)).To(Equal(originalConfigTemplateContent))
+						return nil
+					})
+
+					Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+					Expect(fakeConfigValidator.ValidateCallCount()).To(Equal(1))
+					Expect(os.ReadFile(generatedHaproxyCfgFile)).To(ContainSubstring(marshallerContent))
+					Expect(fakeScriptRunner.RunCallCount()).To(Equal(1))
+				})
+
+				Context("when the config is rejected", func() {
+					BeforeEach(func() {
+						fakeConfigValidator.ValidateReturns(errors.New("[ALERT] parsing error"))
+					})
+
+					It("keeps the current config and does not reload", func() {
+						Expect(haproxyConfigurer.Configure(routingTable, false)).To(MatchError("[ALERT] parsing error"))
+
+						Expect(os.ReadFile(generatedHaproxyCfgFile)).To(Equal(originalConfigTemplateContent))
+						Expect(utils.FileExists(generatedHaproxyCfgFile + ".tmp")).To(BeFalse())
+						Expect(fakeScriptRunner.RunCallCount()).To(Equal(0))
+					})
+
+					It("logs and counts the rejected config", func() {
+						Expect(haproxyConfigurer.Configure(routingTable, false)).ToNot(Succeed())
+
+						Expect(logger).To(gbytes.Say("rejected-config"))
+						Expect(sender.GetCounter("RejectedConfigs")).To(Equal(uint64(1)))
+						Expect(sender.GetCounter("FailedReloads")).To(Equal(uint64(0)))
+					})
+
+					It("keeps watching HAProxy", func() {
+						Expect(haproxyConfigurer.Configure(routingTable, false)).ToNot(Succeed())
+
+						Expect(fakeMonitor.StopWatchingCallCount()).To(Equal(1))
+						Expect(fakeMonitor.StartWatchingCallCount()).To(Equal(1))
+					})
+				})
+			})
+
+			Context("when the reload script fails", func() {
+				var sender *fake.FakeMetricSender
+
+				BeforeEach(func() {
+					sender = fake.NewFakeMetricSender()
+					metrics.Initialize(sender, nil)
+					fakeScriptRunner.RunReturns(errors.New("exit status 1"))
+				})
+
+				It("restores the previous config from the backup", func() {
+					Expect(haproxyConfigurer.Configure(routingTable, false)).To(MatchError("exit status 1"))
+
+					Expect(os.ReadFile(generatedHaproxyCfgFile)).To(Equal(originalConfigTemplateContent))
+					Expect(logger).To(gbytes.Say("restored-config-backup"))
+				})
+
+				It("logs and counts the failed reload", func() {
+					Expect(haproxyConfigurer.Configure(routingTable, false)).ToNot(Succeed())
+
+					Expect(logger).To(gbytes.Say("failed-to-reload-haproxy"))
+					Expect(sender.GetCounter("FailedReloads")).To(Equal(uint64(1)))
+					Expect(sender.GetCounter("RejectedConfigs")).To(Equal(uint64(0)))
+					Expect(sender.GetCounter("Reloads")).To(Equal(uint64(0)))
+				})
+
+				It("keeps watching HAProxy", func() {
+					Expect(haproxyConfigurer.Configure(routingTable, false)).ToNot(Succeed())
+
+					Expect(fakeMonitor.StopWatchingCallCount()).To(Equal(1))
+					Expect(fakeMonitor.StartWatchingCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("when the runtime api is enabled", func() {
+				var (
+					fakeRuntimeAPI *fakes.FakeRuntimeAPI
+					serverSlots    int
+				)
+
+				upsert := func(port uint16, address string) {
+					routingTable.UpsertBackendServerKey(models.RoutingKey{Port: port}, models.BackendServerInfo{Address: address, Port: 8080})
+				}
+
+				JustBeforeEach(func() {
+					fakeRuntimeAPI = new(fakes.FakeRuntimeAPI)
+					cfg := config.Config{
+						BackendTLS: backendTlsCfg,
+						RuntimeAPI: config.RuntimeAPIConfig{Enabled: true, ServerSlots: serverSlots},
+					}
+					haproxyConfigurer, err = haproxy.NewHaProxyConfigurer(logger, fakeMarshaller, haproxyConfigTemplate, generatedHaproxyCfgFile, fakeMonitor, fakeScriptRunner, nil, fakeRuntimeAPI, cfg)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					upsert(80, "10.0.0.1")
+					Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+					Expect(fakeScriptRunner.RunCallCount()).To(Equal(1))
+				})
+
+				BeforeEach(func() {
+					serverSlots = 2
+				})
+
+				It("reloads for the first configuration", func() {
+					Expect(fakeRuntimeAPI.SetServerAddressCallCount()).To(Equal(0))
+					Expect(fakeRuntimeAPI.SetServerStateCallCount()).To(Equal(0))
+				})
+
+				Context("when a server is added to an existing backend", func() {
+					It("points a free server slot at it without reloading", func() {
+						upsert(80, "10.0.0.2")
+						Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+
+						Expect(fakeScriptRunner.RunCallCount()).To(Equal(1))
+						Expect(fakeRuntimeAPI.SetServerAddressCallCount()).To(Equal(1))
+						backend, server, address, port := fakeRuntimeAPI.SetServerAddressArgsForCall(0)
+						Expect([]interface{}{backend, server, address, port}).To(Equal([]interface{}{"backend_80", "slot_1", "10.0.0.2", uint16(8080)}))
+
+						Expect(fakeRuntimeAPI.SetServerStateCallCount()).To(Equal(1))
+						backend, server, state := fakeRuntimeAPI.SetServerStateArgsForCall(0)
+						Expect([]interface{}{backend, server, state}).To(Equal([]interface{}{"backend_80", "slot_1", haproxy.ServerStateReady}))
+					})
+
+					It("still writes the config file and watches HAProxy", func() {
+						upsert(80, "10.0.0.2")
+						Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+
+						Expect(fakeMarshaller.MarshalCallCount()).To(Equal(2))
+						Expect(fakeMonitor.StartWatchingCallCount()).To(Equal(2))
+					})
+				})
+
+				Context("when a server is removed from an existing backend", func() {
+					It("puts it into maintenance without reloading", func() {
+						upsert(80, "10.0.0.2")
+						routingTable.DeleteBackendServerKey(models.RoutingKey{Port: 80}, models.BackendServerInfo{Address: "10.0.0.1", Port: 8080})
+						Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+
+						Expect(fakeScriptRunner.RunCallCount()).To(Equal(1))
+						Expect(fakeRuntimeAPI.SetServerStateCallCount()).To(Equal(2))
+						backend, server, state := fakeRuntimeAPI.SetServerStateArgsForCall(0)
+						Expect([]interface{}{backend, server, state}).To(Equal([]interface{}{"backend_80", "server_10.0.0.1_8080", haproxy.ServerStateMaint}))
+					})
+
+					It("re-enables it when it is added back", func() {
+						upsert(80, "10.0.0.2")
+						routingTable.DeleteBackendServerKey(models.RoutingKey{Port: 80}, models.BackendServerInfo{Address: "10.0.0.1", Port: 8080})
+						Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+						upsert(80, "10.0.0.1")
+						Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+
+						Expect(fakeScriptRunner.RunCallCount()).To(Equal(1))
+						Expect(fakeRuntimeAPI.SetServerAddressCallCount()).To(Equal(1))
+						Expect(fakeRuntimeAPI.SetServerStateCallCount()).To(Equal(3))
+						backend, server, state := fakeRuntimeAPI.SetServerStateArgsForCall(2)
+						Expect([]interface{}{backend, server, state}).To(Equal([]interface{}{"backend_80", "server_10.0.0.1_8080", haproxy.ServerStateReady}))
+					})
+				})
+
+				Context("when a backend is added", func() {
+					It("reloads HAProxy", func() {
+						upsert(81, "10.0.0.2")
+						Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+
+						Expect(fakeScriptRunner.RunCallCount()).To(Equal(2))
+						Expect(fakeRuntimeAPI.SetServerStateCallCount()).To(Equal(0))
+					})
+				})
+
+				Context("when there are no free server slots", func() {
+					BeforeEach(func() {
+						serverSlots = 1
+					})
+
+					It("reloads HAProxy", func() {
+						upsert(80, "10.0.0.2")
+						upsert(80, "10.0.0.3")
+						Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+
+						Expect(fakeScriptRunner.RunCallCount()).To(Equal(2))
+					})
+				})
+
+				Context("when a runtime api command fails", func() {
+					It("reloads HAProxy", func() {
+						fakeRuntimeAPI.SetServerAddressReturns(errors.New("No such server."))
+						upsert(80, "10.0.0.2")
+						Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+
+						Expect(fakeScriptRunner.RunCallCount()).To(Equal(2))
+					})
+				})
+
+				Context("when forceHealthCheckToFail changes", func() {
+					It("reloads HAProxy", func() {
+						upsert(80, "10.0.0.2")
+						Expect(haproxyConfigurer.Configure(routingTable, true)).To(Succeed())
+
+						Expect(fakeScriptRunner.RunCallCount()).To(Equal(2))
+						Expect(fakeRuntimeAPI.SetServerAddressCallCount()).To(Equal(0))
+					})
+				})
+
+				Context("when the router becomes ready", func() {
+					It("reloads HAProxy", func() {
+						fakeReadiness := new(fakes.FakeReadiness)
+						haproxyConfigurer.SetReadiness(fakeReadiness)
+						upsert(80, "10.0.0.2")
+						Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+						Expect(fakeScriptRunner.RunCallCount()).To(Equal(2))
+
+						fakeReadiness.SyncedReturns(true)
+						upsert(80, "10.0.0.3")
+						Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+						Expect(fakeScriptRunner.RunCallCount()).To(Equal(3))
+						Expect(fakeRuntimeAPI.SetServerAddressCallCount()).To(Equal(0))
+					})
+				})
+
+				Context("when the backend tls settings change", func() {
+					It("reloads instead of using server slots", func() {
+						Expect(haproxyConfigurer.SetBackendTLS(backendTlsCfg)).To(Succeed())
+						upsert(80, "10.0.0.2")
+						Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+
+						Expect(fakeScriptRunner.RunCallCount()).To(Equal(2))
+						Expect(fakeRuntimeAPI.SetServerAddressCallCount()).To(Equal(0))
+					})
+				})
+
+				Context("when the reload fails", func() {
+					It("reloads again on the next change", func() {
+						fakeScriptRunner.RunReturns(errors.New("boom"))
+						upsert(81, "10.0.0.2")
+						Expect(haproxyConfigurer.Configure(routingTable, false)).To(HaveOccurred())
+
+						fakeScriptRunner.RunReturns(nil)
+						upsert(81, "10.0.0.3")
+						Expect(haproxyConfigurer.Configure(routingTable, false)).To(Succeed())
+						Expect(fakeScriptRunner.RunCallCount()).To(Equal(3))
+						Expect(fakeRuntimeAPI.SetServerAddressCallCount()).To(Equal(0))
+					})
+				})
+			})
 		})
 	})
 })
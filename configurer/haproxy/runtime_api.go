@@ -0,0 +1,95 @@
+package haproxy
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3"
+)
+
+type ServerState string
+
+const (
+	ServerStateReady ServerState = "ready"
+	ServerStateMaint ServerState = "maint"
+)
+
+//go:generate counterfeiter -o fakes/fake_runtime_api.go . RuntimeAPI
+type RuntimeAPI interface {
+	SetServerAddress(backend string, server string, address string, port uint16) error
+	SetServerState(backend string, server string, state ServerState) error
+}
+
+// RuntimeAPIClient issues commands over the HAProxy stats socket. The socket
+// must be configured with "level admin" for the commands to be accepted.
+type RuntimeAPIClient struct {
+	haproxyUnixSocket string
+	timeout           time.Duration
+	logger            lager.Logger
+}
+
+func NewRuntimeAPIClient(logger lager.Logger, haproxyUnixSocket string, timeout time.Duration) *RuntimeAPIClient {
+	return &RuntimeAPIClient{
+		haproxyUnixSocket: haproxyUnixSocket,
+		timeout:           timeout,
+		logger:            logger.Session("runtime-api"),
+	}
+}
+
+func (r *RuntimeAPIClient) SetServerAddress(backend string, server string, address string, port uint16) error {
+	command := fmt.Sprintf("set server %s/%s addr %s port %d", backend, server, address, port)
+	response, err := r.execute(command)
+	if err != nil {
+		return err
+	}
+	// HAProxy reports what changed, or that there was nothing to change
+	if !strings.Contains(response, "changed from") && !strings.Contains(response, "no need to change") {
+		return fmt.Errorf("%s: %s", command, response)
+	}
+	return nil
+}
+
+func (r *RuntimeAPIClient) SetServerState(backend string, server string, state ServerState) error {
+	command := fmt.Sprintf("set server %s/%s state %s", backend, server, state)
+	response, err := r.execute(command)
+	if err != nil {
+		return err
+	}
+	// HAProxy only responds to a state change when it fails
+	if response != "" {
+		return fmt.Errorf("%s: %s", command, response)
+	}
+	return nil
+}
+
+func (r *RuntimeAPIClient) execute(command string) (string, error) {
+	r.logger.Debug("executing-command", lager.Data{"command": command})
+
+	conn, err := net.DialTimeout("unix", r.haproxyUnixSocket, r.timeout)
+	if err != nil {
+		r.logger.Error("error-connecting-to-haproxy-socket", err)
+		return "", err
+	}
+	defer conn.Close()
+
+	err = conn.SetDeadline(time.Now().Add(r.timeout))
+	if err != nil {
+		return "", err
+	}
+
+	_, err = conn.Write([]byte(command + "\n"))
+	if err != nil {
+		r.logger.Error("error-sending-command", err, lager.Data{"command": command})
+		return "", err
+	}
+
+	response, err := io.ReadAll(conn)
+	if err != nil {
+		r.logger.Error("error-reading-response", err, lager.Data{"command": command})
+		return "", err
+	}
+	return strings.TrimSpace(string(response)), nil
+}
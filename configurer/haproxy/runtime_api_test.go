@@ -0,0 +1,85 @@
+package haproxy_test
+
+import (
+	"net"
+	"os"
+	"path"
+	"time"
+
+	"code.cloudfoundry.org/cf-tcp-router/configurer/haproxy"
+	"code.cloudfoundry.org/cf-tcp-router/testutil"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RuntimeAPIClient", func() {
+	var (
+		runtimeAPI        *haproxy.RuntimeAPIClient
+		haproxyUnixSocket string
+		commands          chan string
+	)
+
+	serve := func(response string) {
+		ready := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			l, err := net.Listen("unix", haproxyUnixSocket)
+			Expect(err).NotTo(HaveOccurred())
+			defer l.Close()
+			close(ready)
+
+			conn, err := l.Accept()
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			buf := make([]byte, 512)
+			n, err := conn.Read(buf)
+			Expect(err).NotTo(HaveOccurred())
+			commands <- string(buf[:n])
+
+			_, err = conn.Write([]byte(response))
+			Expect(err).NotTo(HaveOccurred())
+		}()
+		Eventually(ready).Should(BeClosed())
+	}
+
+	BeforeEach(func() {
+		haproxyUnixSocket = path.Join(os.TempDir(), testutil.RandomFileName("haproxy_", ".sock"))
+		commands = make(chan string, 1)
+		runtimeAPI = haproxy.NewRuntimeAPIClient(logger, haproxyUnixSocket, 100*time.Millisecond)
+	})
+
+	Describe("SetServerState", func() {
+		It("sends the set server state command", func() {
+			serve("\n")
+			Expect(runtimeAPI.SetServerState("backend_80", "slot_1", haproxy.ServerStateMaint)).To(Succeed())
+			Expect(commands).To(Receive(Equal("set server backend_80/slot_1 state maint\n")))
+		})
+
+		It("returns an error when HAProxy responds", func() {
+			serve("No such server.\n")
+			err := runtimeAPI.SetServerState("backend_80", "slot_9", haproxy.ServerStateReady)
+			Expect(err).To(MatchError(ContainSubstring("No such server.")))
+		})
+	})
+
+	Describe("SetServerAddress", func() {
+		It("sends the set server addr command", func() {
+			serve("IP changed from '0.0.0.0' to '10.0.0.1', port changed from '0' to '8080' by 'stats socket command'\n")
+			Expect(runtimeAPI.SetServerAddress("backend_80", "slot_1", "10.0.0.1", 8080)).To(Succeed())
+			Expect(commands).To(Receive(Equal("set server backend_80/slot_1 addr 10.0.0.1 port 8080\n")))
+		})
+
+		It("returns an error when the address is not changed", func() {
+			serve("Invalid addr.\n")
+			err := runtimeAPI.SetServerAddress("backend_80", "slot_1", "not-an-ip", 8080)
+			Expect(err).To(MatchError(ContainSubstring("Invalid addr.")))
+		})
+	})
+
+	Context("when the socket is unavailable", func() {
+		It("returns an error", func() {
+			Expect(runtimeAPI.SetServerState("backend_80", "slot_1", haproxy.ServerStateReady)).NotTo(Succeed())
+		})
+	})
+})
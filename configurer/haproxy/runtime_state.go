@@ -0,0 +1,199 @@
+package haproxy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"code.cloudfoundry.org/cf-tcp-router/config"
+	"code.cloudfoundry.org/cf-tcp-router/models"
+)
+
+var (
+	errBackendsChanged = errors.New("frontends or backends changed")
+	errNoFreeSlots     = errors.New("no free server slots")
+	errTLSServerAdded  = errors.New("tls servers cannot be added to server slots")
+)
+
+// backendRuntimeState records where each server of a backend lives in the
+// running HAProxy process, so that later changes can be applied through the
+// runtime API instead of a reload.
+type backendRuntimeState struct {
+	// servers currently receiving traffic, by the HAProxy server name they use
+	active map[models.HAProxyServer]string
+	// servers from the config file that have been put into maintenance
+	disabled  map[models.HAProxyServer]string
+	freeSlots []string
+}
+
+type runtimeState map[string]*backendRuntimeState
+
+// newRuntimeState describes the servers HAProxy loaded from the config file
+// generated for conf.
+func newRuntimeState(conf models.HAProxyConfig, backendTlsCfg config.BackendTLSConfig, serverSlots int) runtimeState {
+	state := runtimeState{}
+	forEachBackend(conf, func(name string, backend models.HAProxyBackend) {
+		backendState := &backendRuntimeState{
+			active:   map[models.HAProxyServer]string{},
+			disabled: map[models.HAProxyServer]string{},
+		}
+		for _, server := range renderedServers(backend, backendTlsCfg) {
+			backendState.active[server] = serverName(server)
+		}
+		for slot := 1; slot <= serverSlots; slot++ {
+			backendState.freeSlots = append(backendState.freeSlots, serverSlotName(slot))
+		}
+		state[name] = backendState
+	})
+	return state
+}
+
+// apply issues the runtime API commands needed to move HAProxy from the
+// current state to conf and returns the resulting state. An error means the
+// change could not be applied at runtime and HAProxy has to be reloaded.
+func (s runtimeState) apply(conf models.HAProxyConfig, backendTlsCfg config.BackendTLSConfig, runtimeAPI RuntimeAPI) (runtimeState, int, error) {
+	backendCount := 0
+	forEachBackend(conf, func(string, models.HAProxyBackend) { backendCount++ })
+	if backendCount != len(s) {
+		return nil, 0, errBackendsChanged
+	}
+
+	next := runtimeState{}
+	changes := 0
+	var err error
+	forEachBackend(conf, func(name string, backend models.HAProxyBackend) {
+		if err != nil {
+			return
+		}
+		current, ok := s[name]
+		if !ok {
+			err = errBackendsChanged
+			return
+		}
+
+		var backendChanges int
+		next[name], backendChanges, err = current.apply(name, renderedServers(backend, backendTlsCfg), runtimeAPI)
+		changes += backendChanges
+	})
+	if err != nil {
+		return nil, changes, err
+	}
+	return next, changes, nil
+}
+
+func (b *backendRuntimeState) apply(backendName string, servers []models.HAProxyServer, runtimeAPI RuntimeAPI) (*backendRuntimeState, int, error) {
+	next := b.copy()
+	changes := 0
+
+	desired := map[models.HAProxyServer]struct{}{}
+	for _, server := range servers {
+		desired[server] = struct{}{}
+	}
+
+	for _, server := range sortedServers(b.active) {
+		if _, ok := desired[server]; ok {
+			continue
+		}
+		name := b.active[server]
+		err := runtimeAPI.SetServerState(backendName, name, ServerStateMaint)
+		if err != nil {
+			return nil, changes, err
+		}
+		changes++
+
+		delete(next.active, server)
+		if name == serverName(server) {
+			next.disabled[server] = name
+		} else {
+			next.freeSlots = append(next.freeSlots, name)
+		}
+	}
+
+	for _, server := range servers {
+		if _, ok := next.active[server]; ok {
+			continue
+		}
+
+		if name, ok := next.disabled[server]; ok {
+			err := runtimeAPI.SetServerState(backendName, name, ServerStateReady)
+			if err != nil {
+				return nil, changes, err
+			}
+			changes++
+
+			delete(next.disabled, server)
+			next.active[server] = name
+			continue
+		}
+
+		// Slots are rendered without TLS settings, so they can only carry plain TCP servers
+		if server.TLSPort > 0 {
+			return nil, changes, errTLSServerAdded
+		}
+		if len(next.freeSlots) == 0 {
+			return nil, changes, errNoFreeSlots
+		}
+
+		slot := next.freeSlots[0]
+		err := runtimeAPI.SetServerAddress(backendName, slot, server.Address, server.Port)
+		if err != nil {
+			return nil, changes, err
+		}
+		err = runtimeAPI.SetServerState(backendName, slot, ServerStateReady)
+		if err != nil {
+			return nil, changes, err
+		}
+		changes++
+
+		next.freeSlots = next.freeSlots[1:]
+		next.active[server] = slot
+	}
+
+	return next, changes, nil
+}
+
+func (b *backendRuntimeState) copy() *backendRuntimeState {
+	c := &backendRuntimeState{
+		active:    make(map[models.HAProxyServer]string, len(b.active)),
+		disabled:  make(map[models.HAProxyServer]string, len(b.disabled)),
+		freeSlots: append([]string{}, b.freeSlots...),
+	}
+	for server, name := range b.active {
+		c.active[server] = name
+	}
+	for server, name := range b.disabled {
+		c.disabled[server] = name
+	}
+	return c
+}
+
+func forEachBackend(conf models.HAProxyConfig, fn func(name string, backend models.HAProxyBackend)) {
+	for _, port := range sortedHAProxyInboundPorts(conf) {
+		frontend := conf[port]
+		for _, hostname := range sortedSniHostnames(frontend) {
+			fn(models.BackendProxyName(port, hostname), frontend[hostname])
+		}
+	}
+}
+
+// renderedServers returns the servers the marshaller writes out for backend.
+func renderedServers(backend models.HAProxyBackend, backendTlsCfg config.BackendTLSConfig) []models.HAProxyServer {
+	servers := make([]models.HAProxyServer, 0, len(backend))
+	for _, server := range backend {
+		if server.TLSPort > 0 && !backendTlsCfg.Enabled {
+			continue
+		}
+		servers = append(servers, server)
+	}
+	return servers
+}
+
+func sortedServers(servers map[models.HAProxyServer]string) []models.HAProxyServer {
+	keys := make([]models.HAProxyServer, 0, len(servers))
+	for k := range servers {
+		keys = append(keys, k)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool { return fmt.Sprintf("%v", keys[i]) < fmt.Sprintf("%v", keys[j]) })
+	return keys
+}
@@ -1,6 +1,7 @@
 package haproxy
 
 import (
+	"os"
 	"os/exec"
 
 	"code.cloudfoundry.org/lager/v3"
@@ -8,7 +9,7 @@ import (
 
 //go:generate counterfeiter -o fakes/fake_script_runner.go . ScriptRunner
 type ScriptRunner interface {
-	Run(forceHealthCheckToFail bool) error
+	Run(forceHealthCheckToFail bool, ready bool) error
 }
 
 type CommandRunner struct {
@@ -23,14 +24,20 @@ func CreateCommandRunner(scriptPath string, logger lager.Logger) *CommandRunner
 	}
 }
 
-func (cmd *CommandRunner) Run(forceHealthCheckToFail bool) error {
+func (cmd *CommandRunner) Run(forceHealthCheckToFail bool, ready bool) error {
 	runnerCmd := exec.Command(cmd.scriptPath)
+	runnerCmd.Env = os.Environ()
 
 	if forceHealthCheckToFail {
 		cmd.logger.Debug("setting-drain-mode")
 		runnerCmd.Env = append(runnerCmd.Env, "IS_DRAINING=true")
 	}
 
+	if !ready {
+		cmd.logger.Debug("setting-not-ready")
+		runnerCmd.Env = append(runnerCmd.Env, "IS_READY=false")
+	}
+
 	output, err := runnerCmd.CombinedOutput()
 	cmd.logger.Info("running-script", lager.Data{"command": string(cmd.scriptPath), "output": string(output), "error": err})
 	return err
@@ -1,6 +1,8 @@
 package haproxy_test
 
 import (
+	"os"
+
 	. "code.cloudfoundry.org/cf-tcp-router/configurer/haproxy"
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/lager/v3/lagertest"
@@ -24,24 +26,24 @@ var _ = Describe("CommandRunner", func() {
 				cmdRunner = CreateCommandRunner("fixtures/testscript", logger)
 			})
 			It("runs script successfully", func() {
-				err := cmdRunner.Run(false)
+				err := cmdRunner.Run(false, true)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(logger).Should(gbytes.Say("hello test"))
 			})
 			It("logs a useful message", func() {
-				cmdRunner.Run(false)
+				cmdRunner.Run(false, true)
 				logs := logger.(*lagertest.TestLogger).Logs()
 				Expect(len(logs)).To(Equal(1))
 				Expect(logs[0].Message).To(Equal("script-runner-test.running-script"))
 				Expect(logs[0].Data).To(Equal(lager.Data{
 					"command": "fixtures/testscript",
-					"output":  "hello test\nIS_DRAINING=\n",
+					"output":  "hello test\nIS_DRAINING=\nIS_READY=\nINHERITED=\n",
 					"error":   nil,
 				}))
 			})
 			Context("when called with forceHealthCheckToFail set to false", func() {
 				It("launches the runnerCmd without setting IS_DRAINING=true", func() {
-					err := cmdRunner.Run(false)
+					err := cmdRunner.Run(false, true)
 					Expect(err).NotTo(HaveOccurred())
 					Expect(logger).ToNot(gbytes.Say("setting-drain-mode"))
 					Expect(logger).ToNot(gbytes.Say("IS_DRAINING=true"))
@@ -50,13 +52,34 @@ var _ = Describe("CommandRunner", func() {
 			})
 			Context("when called with forceHealthCheckToFail set to true", func() {
 				It("launches the runnerCmd with IS_DRAINING=true", func() {
-					err := cmdRunner.Run(true)
+					err := cmdRunner.Run(true, true)
 					Expect(err).NotTo(HaveOccurred())
 					Expect(logger).To(gbytes.Say("setting-drain-mode"))
 					Expect(logger).To(gbytes.Say("IS_DRAINING=true"))
 
 				})
 			})
+			Context("when called with ready set to false", func() {
+				It("launches the runnerCmd with IS_READY=false", func() {
+					err := cmdRunner.Run(false, false)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(logger).To(gbytes.Say("setting-not-ready"))
+					Expect(logger).To(gbytes.Say("IS_READY=false"))
+				})
+			})
+			Context("when the router has environment variables set", func() {
+				BeforeEach(func() {
+					Expect(os.Setenv("SCRIPT_RUNNER_TEST_INHERITED", "yes")).To(Succeed())
+					DeferCleanup(os.Unsetenv, "SCRIPT_RUNNER_TEST_INHERITED")
+				})
+				It("passes them to the script along with the flags", func() {
+					err := cmdRunner.Run(true, false)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(logger).To(gbytes.Say("IS_DRAINING=true"))
+					Expect(logger).To(gbytes.Say("IS_READY=false"))
+					Expect(logger).To(gbytes.Say("INHERITED=yes"))
+				})
+			})
 		})
 
 		Context("when the underlying script does not exist", func() {
@@ -64,7 +87,7 @@ var _ = Describe("CommandRunner", func() {
 				cmdRunner = CreateCommandRunner("fixtures/non-existent-script", logger)
 			})
 			It("throws error", func() {
-				err := cmdRunner.Run(false)
+				err := cmdRunner.Run(false, true)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("no such file or directory"))
 			})
@@ -75,7 +98,7 @@ var _ = Describe("CommandRunner", func() {
 				cmdRunner = CreateCommandRunner("fixtures/badscript", logger)
 			})
 			It("throws error", func() {
-				err := cmdRunner.Run(false)
+				err := cmdRunner.Run(false, true)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("exit status 1"))
 				Expect(logger).Should(gbytes.Say("negative test"))
@@ -5,19 +5,23 @@ import (
 	"flag"
 	"fmt"
 	"math"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
 	"time"
 
+	"code.cloudfoundry.org/cf-tcp-router/admin_api"
 	"code.cloudfoundry.org/cf-tcp-router/config"
+	"code.cloudfoundry.org/cf-tcp-router/config_reloader"
 	"code.cloudfoundry.org/cf-tcp-router/configurer"
 	"code.cloudfoundry.org/cf-tcp-router/configurer/haproxy"
 	"code.cloudfoundry.org/cf-tcp-router/metrics_reporter"
 	"code.cloudfoundry.org/cf-tcp-router/metrics_reporter/haproxy_client"
 	"code.cloudfoundry.org/cf-tcp-router/models"
 	"code.cloudfoundry.org/cf-tcp-router/monitor"
+	"code.cloudfoundry.org/cf-tcp-router/retry"
 	"code.cloudfoundry.org/cf-tcp-router/router_group_port_checker"
 	"code.cloudfoundry.org/cf-tcp-router/routing_table"
 	"code.cloudfoundry.org/cf-tcp-router/syncer"
@@ -32,6 +36,7 @@ import (
 	"github.com/cloudfoundry/dropsonde"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/grouper"
+	"github.com/tedsuo/ifrit/http_server"
 	"github.com/tedsuo/ifrit/sigmon"
 )
 
@@ -67,7 +72,37 @@ var tcpLoadBalancerStatsUnixSocket = flag.String(
 var subscriptionRetryInterval = flag.Int(
 	"subscriptionRetryInterval",
 	5,
-	"Retry interval between retries to subscribe for tcp events from routing api (in seconds)",
+	"Initial delay before retrying calls to routing api, such as subscribing for tcp events (in seconds)",
+)
+
+var retryMaxDelay = flag.Duration(
+	"retryMaxDelay",
+	time.Minute,
+	"The maximum delay between retries of calls to routing api.",
+)
+
+var retryMultiplier = flag.Float64(
+	"retryMultiplier",
+	2,
+	"The factor the delay between retries of calls to routing api grows by after each consecutive failure.",
+)
+
+var retryJitter = flag.Float64(
+	"retryJitter",
+	0.2,
+	"The fraction of the delay between retries of calls to routing api that is randomized, between 0 and 1.",
+)
+
+var retryMaxAttempts = flag.Int(
+	"retryMaxAttempts",
+	3,
+	"The number of attempts made for a routing api sync or router group lookup before giving up until the next one. Set to 0 to retry until they succeed. Subscribing for tcp events is always retried.",
+)
+
+var eventStreamIdleTimeout = flag.Duration(
+	"eventStreamIdleTimeout",
+	2*time.Minute,
+	"How long the routing api event stream may go without delivering an event before the router subscribes again and syncs. Set to 0 to disable.",
 )
 
 var configFile = flag.String(
@@ -88,6 +123,12 @@ var haproxyReloader = flag.String(
 	"Path to a script that reloads HAProxy.",
 )
 
+var haproxyConfigValidator = flag.String(
+	"haproxyConfigValidator",
+	"",
+	"Command that validates a generated HAProxy config before it is applied, e.g. 'haproxy -c -f'. The config file path is appended to its arguments. Validation is skipped when empty.",
+)
+
 var syncInterval = flag.Duration(
 	"syncInterval",
 	time.Minute,
@@ -136,6 +177,24 @@ var defaultRouteExpiry = flag.Duration(
 	"The default ttl for a route",
 )
 
+var backendTLSCertCheckInterval = flag.Duration(
+	"backendTLSCertCheckInterval",
+	30*time.Second,
+	"The interval at which the backend TLS certificate files are checked for changes.",
+)
+
+var reconfigureBatchWindow = flag.Duration(
+	"reconfigureBatchWindow",
+	0,
+	"The time to wait for further routing table changes before reconfiguring the tcp load balancer. Set to 0 to reconfigure on every change, which is the default.",
+)
+
+var reconfigureMaxDelay = flag.Duration(
+	"reconfigureMaxDelay",
+	5*time.Second,
+	"The maximum time a routing table change is held back while batching reconfigurations of the tcp load balancer.",
+)
+
 const (
 	dropsondeOrigin        = "tcp-router"
 	statsConnectionTimeout = 10 * time.Second
@@ -160,19 +219,38 @@ func main() {
 	if len(cfg.IsolationSegments) > 0 {
 		logger.Info("retrieved-isolation-segments", map[string]interface{}{"isolation_segments": fmt.Sprintf("[%s]", strings.Join(cfg.IsolationSegments, ","))})
 	}
+	if len(cfg.RouterGroups) > 0 {
+		logger.Info("retrieved-router-groups", lager.Data{"router_groups": fmt.Sprintf("[%s]", strings.Join(cfg.RouterGroups, ","))})
+	}
+
+	var prometheusEmitter *metrics_reporter.PrometheusEmitter
+	if cfg.Metrics.Emitter == config.PrometheusMetricsEmitter {
+		prometheusEmitter = metrics_reporter.NewPrometheusEmitter()
+		metrics_reporter.SetMetricSink(prometheusEmitter)
+	}
 
 	monitor := monitor.New(cfg.HaProxyPidFile, logger)
 
 	routingTable := models.NewRoutingTable(logger)
 	reloaderRunner := haproxy.CreateCommandRunner(*haproxyReloader, logger)
-	configurer := configurer.NewConfigurer(
+	var configValidator haproxy.ConfigValidator
+	if *haproxyConfigValidator != "" {
+		configValidator = haproxy.CreateCommandValidator(*haproxyConfigValidator, logger)
+	}
+	var runtimeAPI haproxy.RuntimeAPI
+	if cfg.RuntimeAPI.Enabled {
+		runtimeAPI = haproxy.NewRuntimeAPIClient(logger, *tcpLoadBalancerStatsUnixSocket, statsConnectionTimeout)
+	}
+	routerConfigurer := configurer.NewConfigurer(
 		logger,
 		*tcpLoadBalancer,
 		*tcpLoadBalancerBaseCfg,
 		*tcpLoadBalancerCfg,
 		monitor,
 		reloaderRunner,
-		cfg.BackendTLS,
+		configValidator,
+		runtimeAPI,
+		*cfg,
 	)
 
 	// Reap child processes to prevent zombies when running in a container (BPM)
@@ -201,6 +279,33 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *reconfigureMaxDelay < *reconfigureBatchWindow {
+		logger.Error("invalid-reconfigure-max-delay", errors.New("reconfigure max delay cannot be less than reconfigure batch window"))
+		os.Exit(1)
+	}
+
+	// Backend TLS settings and readiness are applied to the configurer itself, not to the batching wrapper
+	backendTLSSetter, _ := routerConfigurer.(config_reloader.BackendTLSSetter)
+	haproxyConfigurer, _ := routerConfigurer.(*haproxy.Configurer)
+
+	retryPolicy := retry.Policy{
+		InitialDelay: time.Duration(*subscriptionRetryInterval) * time.Second,
+		MaxDelay:     *retryMaxDelay,
+		Multiplier:   *retryMultiplier,
+		Jitter:       *retryJitter,
+		MaxAttempts:  *retryMaxAttempts,
+	}
+	if err := retryPolicy.Validate(); err != nil {
+		logger.Error("invalid-retry-policy", err)
+		os.Exit(1)
+	}
+
+	var batchingConfigurer *configurer.BatchingConfigurer
+	if *reconfigureBatchWindow > 0 {
+		batchingConfigurer = configurer.NewBatchingConfigurer(logger, routerConfigurer, clock, *reconfigureBatchWindow, *reconfigureMaxDelay)
+		routerConfigurer = batchingConfigurer
+	}
+
 	uaaConfig := uaaclient.Config{
 		Port:              cfg.OAuth.Port,
 		SkipSSLValidation: cfg.OAuth.SkipSSLValidation,
@@ -242,10 +347,23 @@ func main() {
 	routingAPIClient = routing_api.NewClientWithTLSConfig(routingAPIAddress, tlsConfig)
 
 	logger.Debug("creating-routing-api-client", lager.Data{"api-location": routingAPIAddress})
-	portChecker := router_group_port_checker.NewPortChecker(routingAPIClient, uaaTokenFetcher)
+	portChecker := router_group_port_checker.NewPortChecker(routingAPIClient, uaaTokenFetcher, clock, retryPolicy)
 	checkPorts(logger, portChecker, cfg)
 
-	updater := routing_table.NewUpdater(logger, &routingTable, configurer, routingAPIClient, uaaTokenFetcher, clock, int(defaultRouteExpiry.Seconds()), cfg.DrainWaitDuration)
+	routeFilter := routing_table.RouteFilter{IsolationSegments: cfg.IsolationSegments}
+	if len(cfg.RouterGroups) > 0 {
+		routeFilter.RouterGroupGuids, err = portChecker.RouterGroupGuids(cfg.RouterGroups)
+		if err != nil {
+			logger.Error("failed-to-look-up-router-groups", err)
+			os.Exit(1)
+		}
+	}
+
+	updater := routing_table.NewUpdater(logger, &routingTable, routerConfigurer, routingAPIClient, uaaTokenFetcher, clock, int(defaultRouteExpiry.Seconds()), cfg.DrainWaitDuration, routeFilter, retryPolicy)
+
+	if haproxyConfigurer != nil {
+		haproxyConfigurer.SetReadiness(updater)
+	}
 
 	ticker := clock.NewTicker(*staleRouteCheckInterval)
 
@@ -253,17 +371,59 @@ func main() {
 
 	syncChannel := make(chan struct{})
 	syncRunner := syncer.New(clock, *syncInterval, syncChannel, logger)
-	watcher := watcher.New(routingAPIClient, updater, uaaTokenFetcher, *subscriptionRetryInterval, syncChannel, logger)
+	watcher := watcher.New(routingAPIClient, updater, uaaTokenFetcher, clock, retryPolicy, *eventStreamIdleTimeout, syncChannel, logger)
 
 	haproxyClient := haproxy_client.NewClient(logger, *tcpLoadBalancerStatsUnixSocket, statsConnectionTimeout)
-	metricsEmitter := metrics_reporter.NewMetricsEmitter()
-	metricsReporter := metrics_reporter.NewMetricsReporter(clock, haproxyClient, metricsEmitter, *statsCollectionInterval, logger)
+	var metricsEmitter metrics_reporter.MetricsEmitter
+	if prometheusEmitter != nil {
+		metricsEmitter = prometheusEmitter
+	} else {
+		metricsEmitter = metrics_reporter.NewMetricsEmitter()
+	}
+	metricsReporter := metrics_reporter.NewMetricsReporter(clock, haproxyClient, updater, metricsEmitter, *statsCollectionInterval, logger)
+
+	reloadSignals := make(chan os.Signal, 1)
+	signal.Notify(reloadSignals, syscall.SIGHUP)
+	certWatcher := config_reloader.NewCertWatcher(logger, clock, *backendTLSCertCheckInterval, cfg.BackendTLS, backendTLSSetter, updater)
+	configReloader := config_reloader.New(logger, *configFile, *cfg, reloadSignals, certWatcher, updater, &portChecker)
 
 	members := grouper.Members{
 		{Name: "syncer", Runner: syncRunner},
 		{Name: "metricsReporter", Runner: metricsReporter},
 		{Name: "monitor", Runner: monitor},
-		{Name: "watcher", Runner: watcher},
+		{Name: "certWatcher", Runner: certWatcher},
+		{Name: "configReloader", Runner: configReloader},
+	}
+
+	if prometheusEmitter != nil {
+		mux := http.NewServeMux()
+		mux.Handle(metrics_reporter.PrometheusMetricsPath, prometheusEmitter)
+		prometheusServer := http_server.New(fmt.Sprintf(":%d", cfg.Metrics.PrometheusPort), mux)
+		members = append(members, grouper.Member{Name: "prometheus-server", Runner: prometheusServer})
+	}
+
+	if cfg.AdminAPI.Enabled {
+		adminServer, err := admin_api.NewServer(logger, cfg.AdminAPI, updater)
+		if err != nil {
+			logger.Fatal("failed-to-create-admin-api-server", err)
+		}
+		members = append(members, grouper.Member{Name: "admin-api", Runner: adminServer})
+	}
+
+	if cfg.Readiness.Enabled {
+		healthServer := admin_api.NewHealthServer(logger, cfg.Readiness, updater)
+		members = append(members, grouper.Member{Name: "health-server", Runner: healthServer})
+	}
+
+	// The ordered group only passes SIGUSR2 to its last member, so the watcher,
+	// which starts the drain, must stay last. Members added before it keep
+	// running while the router drains.
+	members = append(members, grouper.Member{Name: "watcher", Runner: watcher})
+
+	if batchingConfigurer != nil {
+		members = append(grouper.Members{
+			{Name: "batchingConfigurer", Runner: batchingConfigurer},
+		}, members...)
 	}
 
 	if dbgAddr := debugserver.DebugAddress(flag.CommandLine); dbgAddr != "" {
@@ -256,6 +256,10 @@ func getRouterGroupGuid(routingApiClient routing_api.Client) string {
 }
 
 func generateTCPRouterConfigFile(oauthServerPort uint16, uaaCACertsPath string, routingApiAuthDisabled bool, reserved_routing_ports ...uint16) string {
+	return writeTCPRouterConfigFile(generateTCPRouterConfig(oauthServerPort, uaaCACertsPath, routingApiAuthDisabled, reserved_routing_ports...))
+}
+
+func generateTCPRouterConfig(oauthServerPort uint16, uaaCACertsPath string, routingApiAuthDisabled bool, reserved_routing_ports ...uint16) config.Config {
 	tcpRouterConfig := config.Config{
 		ReservedSystemComponentPorts: reserved_routing_ports,
 		OAuth: config.OAuthConfig{
@@ -281,7 +285,10 @@ func generateTCPRouterConfigFile(oauthServerPort uint16, uaaCACertsPath string,
 	tcpRouterConfig.RoutingAPI.ClientCertificatePath = routingAPIClientCertPath
 	tcpRouterConfig.RoutingAPI.ClientPrivateKeyPath = routingAPIClientPrivateKeyPath
 	tcpRouterConfig.RoutingAPI.CACertificatePath = routingAPICAFileName
+	return tcpRouterConfig
+}
 
+func writeTCPRouterConfigFile(tcpRouterConfig config.Config) string {
 	bs, err := yaml.Marshal(tcpRouterConfig)
 	Expect(err).NotTo(HaveOccurred())
 
@@ -13,6 +13,8 @@ import (
 	"syscall"
 	"time"
 
+	tls_helpers "code.cloudfoundry.org/cf-routing-test-helpers/tls"
+	"code.cloudfoundry.org/cf-tcp-router/config"
 	"code.cloudfoundry.org/cf-tcp-router/testrunner"
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/lager/v3/lagertest"
@@ -268,6 +270,62 @@ var _ = Describe("Main", func() {
 		})
 	})
 
+	Context("when the admin api and the readiness endpoint are enabled", Serial, func() {
+		var readinessPort uint16
+
+		healthStatus := func() int {
+			resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/health", readinessPort))
+			if err != nil {
+				return 0
+			}
+			defer resp.Body.Close()
+			return resp.StatusCode
+		}
+
+		BeforeEach(func() {
+			oauthServer = oAuthServer(logger, uaaServCert)
+			server = routingApiServer(logger)
+			routerGroupGuid = getRouterGroupGuid(routingApiClient)
+			oauthServerPort := getServerPort(oauthServer.URL())
+
+			adminCertPath, adminKeyPath, _ := tls_helpers.GenerateCertAndKey(routingAPICAFileName, routingAPICAPrivateKey)
+			readinessPort = nextAvailPort()
+			tcpRouterConfig := generateTCPRouterConfig(oauthServerPort, uaaCAPath, false)
+			tcpRouterConfig.AdminAPI = config.AdminAPIConfig{
+				Enabled:          true,
+				Port:             nextAvailPort(),
+				CertPath:         adminCertPath,
+				KeyPath:          adminKeyPath,
+				ClientCACertPath: routingAPICAFileName,
+			}
+			tcpRouterConfig.Readiness = config.ReadinessConfig{Enabled: true, Port: readinessPort}
+			tcpRouterArgs := testrunner.Args{
+				BaseLoadBalancerConfigFilePath: haproxyBaseConfigFile,
+				LoadBalancerConfigFilePath:     haproxyConfigFile,
+				ConfigFilePath:                 writeTCPRouterConfigFile(tcpRouterConfig),
+			}
+
+			allOutput := logger.Buffer()
+			runner := testrunner.New(tcpRouterPath, tcpRouterArgs)
+			var err error
+			session, err = gexec.Start(runner.Command, allOutput, allOutput)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("drains when signaled with SIGUSR2 and reports the drain until it exits", func() {
+			Eventually(session.Out, 5*time.Second).Should(gbytes.Say("applied-fetched-routes-to-routing-table"))
+			Eventually(healthStatus).Should(Equal(http.StatusOK))
+
+			session.Signal(syscall.SIGUSR2)
+			Eventually(session.Out).Should(gbytes.Say("drain-requested"))
+			Eventually(session.Out).Should(gbytes.Say("starting-drain-wait"))
+			Expect(healthStatus()).To(Equal(http.StatusServiceUnavailable))
+
+			Eventually(session.Out, 5*time.Second).Should(gbytes.Say("finished-drain-wait"))
+			Eventually(session.Exited, 5*time.Second).Should(BeClosed())
+		})
+	})
+
 	Context("when systemComponentPorts conflict", func() {
 		BeforeEach(func() {
 			oauthServer = oAuthServer(logger, uaaServCert)
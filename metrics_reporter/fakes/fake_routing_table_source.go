@@ -0,0 +1,103 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/cf-tcp-router/metrics_reporter"
+	"code.cloudfoundry.org/cf-tcp-router/models"
+)
+
+type FakeRoutingTableSource struct {
+	RoutingTableStub        func() models.RoutingTable
+	routingTableMutex       sync.RWMutex
+	routingTableArgsForCall []struct {
+	}
+	routingTableReturns struct {
+		result1 models.RoutingTable
+	}
+	routingTableReturnsOnCall map[int]struct {
+		result1 models.RoutingTable
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeRoutingTableSource) RoutingTable() models.RoutingTable {
+	fake.routingTableMutex.Lock()
+	ret, specificReturn := fake.routingTableReturnsOnCall[len(fake.routingTableArgsForCall)]
+	fake.routingTableArgsForCall = append(fake.routingTableArgsForCall, struct {
+	}{})
+	stub := fake.RoutingTableStub
+	fakeReturns := fake.routingTableReturns
+	fake.recordInvocation("RoutingTable", []interface{}{})
+	fake.routingTableMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRoutingTableSource) RoutingTableCallCount() int {
+	fake.routingTableMutex.RLock()
+	defer fake.routingTableMutex.RUnlock()
+	return len(fake.routingTableArgsForCall)
+}
+
+func (fake *FakeRoutingTableSource) RoutingTableCalls(stub func() models.RoutingTable) {
+	fake.routingTableMutex.Lock()
+	defer fake.routingTableMutex.Unlock()
+	fake.RoutingTableStub = stub
+}
+
+func (fake *FakeRoutingTableSource) RoutingTableReturns(result1 models.RoutingTable) {
+	fake.routingTableMutex.Lock()
+	defer fake.routingTableMutex.Unlock()
+	fake.RoutingTableStub = nil
+	fake.routingTableReturns = struct {
+		result1 models.RoutingTable
+	}{result1}
+}
+
+func (fake *FakeRoutingTableSource) RoutingTableReturnsOnCall(i int, result1 models.RoutingTable) {
+	fake.routingTableMutex.Lock()
+	defer fake.routingTableMutex.Unlock()
+	fake.RoutingTableStub = nil
+	if fake.routingTableReturnsOnCall == nil {
+		fake.routingTableReturnsOnCall = make(map[int]struct {
+			result1 models.RoutingTable
+		})
+	}
+	fake.routingTableReturnsOnCall[i] = struct {
+		result1 models.RoutingTable
+	}{result1}
+}
+
+func (fake *FakeRoutingTableSource) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.routingTableMutex.RLock()
+	defer fake.routingTableMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeRoutingTableSource) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ metrics_reporter.RoutingTableSource = new(FakeRoutingTableSource)
@@ -3,9 +3,12 @@ package haproxy_client
 import (
 	"bytes"
 	"encoding/csv"
+	"errors"
 	"io"
 	"net"
+	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"code.cloudfoundry.org/lager/v3"
@@ -24,11 +27,40 @@ type HaproxyStatsClient struct {
 
 type HaproxyStats []HaproxyStat
 
+// Rows for the aggregate of a proxy use these in place of a server name.
+const (
+	FrontendServerName = "FRONTEND"
+	BackendServerName  = "BACKEND"
+)
+
+// HaproxyStat holds the columns of a `show stat` row that the router uses.
+// Columns are located by the csv tag of each field in the header row, and
+// columns missing from the header are left at their zero value.
 type HaproxyStat struct {
 	ProxyName            string `csv:"pxname"`
+	ServerName           string `csv:"svname"`
+	ServerAddress        string `csv:"addr"`
+	Status               string `csv:"status"`
 	CurrentQueued        uint64 `csv:"qcur"`
 	CurrentSessions      uint64 `csv:"scur"`
+	TotalSessions        uint64 `csv:"stot"`
+	SessionRate          uint64 `csv:"rate"`
+	BytesIn              uint64 `csv:"bin"`
+	BytesOut             uint64 `csv:"bout"`
 	ErrorConnecting      uint64 `csv:"econ"`
+	DeniedConnections    uint64 `csv:"dcon"`
+	DeniedSessions       uint64 `csv:"dses"`
+	DeniedRequests       uint64 `csv:"dreq"`
+	CheckFailures        uint64 `csv:"chkfail"`
+	CheckStatus          string `csv:"check_status"`
+	DowntimeSeconds      uint64 `csv:"downtime"`
+	LastChangeSeconds    uint64 `csv:"lastchg"`
+	HTTPResponses1xx     uint64 `csv:"hrsp_1xx"`
+	HTTPResponses2xx     uint64 `csv:"hrsp_2xx"`
+	HTTPResponses3xx     uint64 `csv:"hrsp_3xx"`
+	HTTPResponses4xx     uint64 `csv:"hrsp_4xx"`
+	HTTPResponses5xx     uint64 `csv:"hrsp_5xx"`
+	HTTPResponsesOther   uint64 `csv:"hrsp_other"`
 	AverageQueueTimeMs   uint64 `csv:"qtime"`
 	AverageConnectTimeMs uint64 `csv:"ctime"`
 	AverageSessionTimeMs uint64 `csv:"ttime"`
@@ -92,33 +124,67 @@ func readCsv(logger lager.Logger, buffer []byte) HaproxyStats {
 
 	bReader := bytes.NewReader(buffer)
 	csvReader := csv.NewReader(bReader)
+	// Rows may have fewer columns than the header; missing columns are left empty
+	csvReader.FieldsPerRecord = -1
 
 	lines, err := csvReader.ReadAll()
 	if err != nil {
 		logger.Error("error-reading-csv-stats", err)
 		return stats
 	}
+	if len(lines) == 0 {
+		return stats
+	}
 
-	for i, line := range lines {
+	columns, err := parseHeader(lines[0])
+	if err != nil {
+		logger.Error("error-reading-csv-header", err)
+		return stats
+	}
+
+	for _, line := range lines[1:] {
+		stats = append(stats, csvToHaproxyStat(columns, line))
+	}
+	return stats
+}
+
+// parseHeader maps column names to their index from a header row such as
+// "# pxname,svname,qcur,...".
+func parseHeader(header []string) (map[string]int, error) {
+	if len(header) == 0 || !strings.HasPrefix(header[0], "#") {
+		return nil, errors.New("missing stats header")
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
 		if i == 0 {
-			// skip header line
-			continue
+			name = strings.TrimPrefix(name, "#")
 		}
-		stats = append(stats, csvToHaproxyStat(line))
+		columns[strings.TrimSpace(name)] = i
 	}
-	return stats
+	return columns, nil
 }
 
-func csvToHaproxyStat(row []string) HaproxyStat {
-	return HaproxyStat{
-		ProxyName:            row[0],
-		CurrentQueued:        convertToInt(row[2]),
-		CurrentSessions:      convertToInt(row[4]),
-		ErrorConnecting:      convertToInt(row[13]),
-		AverageQueueTimeMs:   convertToInt(row[58]),
-		AverageConnectTimeMs: convertToInt(row[59]),
-		AverageSessionTimeMs: convertToInt(row[61]),
+func csvToHaproxyStat(columns map[string]int, row []string) HaproxyStat {
+	stat := HaproxyStat{}
+
+	v := reflect.ValueOf(&stat).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		column, ok := columns[t.Field(i).Tag.Get("csv")]
+		if !ok || column >= len(row) {
+			continue
+		}
+
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(row[column])
+		case reflect.Uint64:
+			field.SetUint(convertToInt(row[column]))
+		}
 	}
+	return stat
 }
 
 func convertToInt(s string) uint64 {
@@ -86,8 +86,12 @@ var _ = Describe("HaproxyClient", func() {
 
 				r0 := haproxy_client.HaproxyStat{
 					ProxyName:            "stats",
+					ServerName:           "FRONTEND",
+					Status:               "OPEN",
 					CurrentQueued:        100,
 					CurrentSessions:      101,
+					TotalSessions:        1,
+					SessionRate:          1,
 					ErrorConnecting:      102,
 					AverageQueueTimeMs:   103,
 					AverageConnectTimeMs: 104,
@@ -96,9 +100,18 @@ var _ = Describe("HaproxyClient", func() {
 
 				r8 := haproxy_client.HaproxyStat{
 					ProxyName:            "listen_cfg_60001",
+					ServerName:           "BACKEND",
+					Status:               "UP",
 					CurrentQueued:        1000,
 					CurrentSessions:      1001,
+					TotalSessions:        1006,
+					SessionRate:          1007,
+					BytesIn:              1008,
+					BytesOut:             1009,
 					ErrorConnecting:      1002,
+					CheckFailures:        1010,
+					DowntimeSeconds:      1011,
+					LastChangeSeconds:    1012,
 					AverageQueueTimeMs:   1003,
 					AverageConnectTimeMs: 1004,
 					AverageSessionTimeMs: 1005,
@@ -109,6 +122,91 @@ var _ = Describe("HaproxyClient", func() {
 			})
 		})
 
+		Context("when haproxy reports columns in a different order", func() {
+			BeforeEach(func() {
+				readyChannel := make(chan struct{})
+				csvPayload, err := os.ReadFile("fixtures/reordered.csv")
+				Expect(err).NotTo(HaveOccurred())
+
+				go setupUnixSocketServer(csvPayload, haproxyUnixSocket, readyChannel)
+				haproxyClient = haproxy_client.NewClient(logger, haproxyUnixSocket, timeout)
+				Eventually(readyChannel).Should(BeClosed())
+			})
+
+			It("locates columns using the header and leaves missing columns empty", func() {
+				stats := haproxyClient.GetStats()
+				Expect(stats).To(Equal(haproxy_client.HaproxyStats{
+					{
+						ProxyName:       "backend_9000",
+						ServerName:      "BACKEND",
+						Status:          "UP",
+						CurrentSessions: 7,
+						ErrorConnecting: 3,
+						BytesIn:         512,
+					},
+					{
+						ProxyName:       "backend_9000",
+						ServerName:      "server_10.0.0.1_8080",
+						ServerAddress:   "10.0.0.1:8080",
+						Status:          "DOWN",
+						CurrentSessions: 7,
+						ErrorConnecting: 3,
+						BytesIn:         512,
+						CheckStatus:     "L4CON",
+						CheckFailures:   4,
+					},
+					{
+						ProxyName:         "frontend_9000",
+						ServerName:        "FRONTEND",
+						Status:            "OPEN",
+						CurrentSessions:   7,
+						BytesIn:           512,
+						DeniedConnections: 9,
+					},
+				}))
+			})
+		})
+
+		Context("when a row has fewer columns than the header", func() {
+			BeforeEach(func() {
+				readyChannel := make(chan struct{})
+				csvPayload, err := os.ReadFile("fixtures/truncated.csv")
+				Expect(err).NotTo(HaveOccurred())
+
+				go setupUnixSocketServer(csvPayload, haproxyUnixSocket, readyChannel)
+				haproxyClient = haproxy_client.NewClient(logger, haproxyUnixSocket, timeout)
+				Eventually(readyChannel).Should(BeClosed())
+			})
+
+			It("parses the other rows and leaves the missing columns of the short row empty", func() {
+				stats := haproxyClient.GetStats()
+				Expect(stats).To(HaveLen(3))
+				Expect(stats[0].ProxyName).To(Equal("stats"))
+				Expect(stats[0].CurrentSessions).To(Equal(uint64(101)))
+				Expect(stats[1].Status).To(Equal("UP"))
+				Expect(stats[2]).To(Equal(haproxy_client.HaproxyStat{
+					ProxyName:       "listen_cfg_60000",
+					ServerName:      "FRONTEND",
+					CurrentSessions: 5,
+				}))
+			})
+		})
+
+		Context("when haproxy statistics have no header", func() {
+			BeforeEach(func() {
+				readyChannel := make(chan struct{})
+				go setupUnixSocketServer([]byte("backend_9000,BACKEND,0\n"), haproxyUnixSocket, readyChannel)
+				haproxyClient = haproxy_client.NewClient(logger, haproxyUnixSocket, timeout)
+				Eventually(readyChannel).Should(BeClosed())
+			})
+
+			It("returns empty haproxy statistics", func() {
+				stats := haproxyClient.GetStats()
+				Expect(stats).Should(HaveLen(0))
+				Expect(logger).Should(gbytes.Say("test.get-stats.error-reading-csv-header"))
+			})
+		})
+
 		Context("when haproxy does not provide statistics", func() {
 			BeforeEach(func() {
 				readyChannel := make(chan struct{})
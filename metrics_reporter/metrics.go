@@ -2,30 +2,65 @@ package metrics_reporter
 
 import "github.com/cloudfoundry/dropsonde/metrics"
 
+// MetricSink receives every metric sent through the types in this file.
+type MetricSink interface {
+	SendValue(name string, value float64, unit string)
+	SendProxyValue(proxyName string, name string, value float64, unit string)
+	AddToCounter(name string, delta uint64)
+}
+
+var sink MetricSink = dropsondeSink{}
+
+// SetMetricSink replaces the default sink, which forwards metrics to the
+// local metron agent through dropsonde. It is meant to be called once at
+// startup, before any metrics are sent.
+func SetMetricSink(s MetricSink) {
+	sink = s
+}
+
+type dropsondeSink struct{}
+
+func (dropsondeSink) SendValue(name string, value float64, unit string) {
+	// #nosec G104 - don't log failures sending metrics to avoid spamming logs
+	metrics.SendValue(name, value, unit)
+}
+
+func (dropsondeSink) SendProxyValue(proxyName string, name string, value float64, unit string) {
+	// #nosec G104 - don't log failures sending metrics to avoid spamming logs
+	metrics.SendValue(proxyName+"."+name, value, unit)
+}
+
+func (dropsondeSink) AddToCounter(name string, delta uint64) {
+	// #nosec G104 - don't log failures sending metrics to avoid spamming logs
+	metrics.AddToCounter(name, delta)
+}
+
 type Value string
 
 func (name Value) Send(value uint64) {
-	// #nosec G104 - don't log failures sending metrics to avoid spamming logs
-	metrics.SendValue(string(name), float64(value), "Metric")
+	sink.SendValue(string(name), float64(value), "Metric")
 }
 
 type ProxyValue string
 
 func (name ProxyValue) Send(proxyName string, value uint64) {
-	// #nosec G104 - don't log failures sending metrics to avoid spamming logs
-	metrics.SendValue(proxyName+"."+string(name), float64(value), "Metric")
+	sink.SendProxyValue(proxyName, string(name), float64(value), "Metric")
 }
 
 type ProxyDurationMs string
 
 func (name ProxyDurationMs) Send(proxyName string, duration uint64) {
-	// #nosec G104 - don't log failures sending metrics to avoid spamming logs
-	metrics.SendValue(proxyName+"."+string(name), float64(duration), "ms")
+	sink.SendProxyValue(proxyName, string(name), float64(duration), "ms")
 }
 
 type DurationMs string
 
 func (name DurationMs) Send(duration uint64) {
-	// #nosec G104 - don't log failures sending metrics to avoid spamming logs
-	metrics.SendValue(string(name), float64(duration), "ms")
+	sink.SendValue(string(name), float64(duration), "ms")
+}
+
+type Counter string
+
+func (name Counter) Add(delta uint64) {
+	sink.AddToCounter(string(name), delta)
 }
@@ -1,7 +1,7 @@
 package metrics_reporter
 
 import (
-	"errors"
+	"net"
 	"strconv"
 	"strings"
 
@@ -24,10 +24,14 @@ func Convert(proxyStats haproxy_client.HaproxyStats) *MetricsReport {
 		totalConnectTimeMs           uint64
 		proxyStatsMap                map[models.RoutingKey]ProxyStats
 		routeErrorMap                map[string]uint64
+		deniedConnectionsMap         map[models.RoutingKey]uint64
+		rateLimitedConnectionsMap    map[models.RoutingKey]uint64
 	)
 
 	proxyStatsMap = map[models.RoutingKey]ProxyStats{}
 	routeErrorMap = map[string]uint64{}
+	deniedConnectionsMap = map[models.RoutingKey]uint64{}
+	rateLimitedConnectionsMap = map[models.RoutingKey]uint64{}
 
 	length := uint64(len(proxyStats))
 
@@ -39,6 +43,7 @@ func Convert(proxyStats haproxy_client.HaproxyStats) *MetricsReport {
 
 		routeErrorMap[proxyStat.ProxyName] += proxyStat.ErrorConnecting
 		populateProxyStats(proxyStat, proxyStatsMap)
+		populateDeniedConnections(proxyStat, deniedConnectionsMap, rateLimitedConnectionsMap)
 
 	}
 	averageQueueTimeMs = totalQueueTimeMs / length
@@ -51,35 +56,102 @@ func Convert(proxyStats haproxy_client.HaproxyStats) *MetricsReport {
 		AverageConnectTimeMs:         averageConnectTimeMs,
 		ProxyMetrics:                 proxyStatsMap,
 		RouteErrorMap:                routeErrorMap,
+		DeniedConnections:            deniedConnectionsMap,
+		RateLimitedConnections:       rateLimitedConnectionsMap,
 	}
 }
 
+// Access control rejects connections with connection rules, or with session
+// rules behind the PROXY protocol, and rate limits with content rules, which
+// HAProxy counts as denied requests.
+func populateDeniedConnections(proxyStat haproxy_client.HaproxyStat, deniedConnectionsMap map[models.RoutingKey]uint64, rateLimitedConnectionsMap map[models.RoutingKey]uint64) {
+	if proxyStat.ServerName != haproxy_client.FrontendServerName {
+		return
+	}
+	proxyType, key, err := models.ParseProxyName(proxyStat.ProxyName)
+	if err != nil || proxyType != models.FrontendProxy {
+		return
+	}
+	deniedConnectionsMap[key] += proxyStat.DeniedConnections + proxyStat.DeniedSessions
+	rateLimitedConnectionsMap[key] += proxyStat.DeniedRequests
+}
+
+// Per-route stats come from the aggregate row of each backend only: a
+// frontend serves every route on its port and would otherwise be counted
+// against the non-SNI route, and server rows are already included in the
+// aggregate.
 func populateProxyStats(proxyStat haproxy_client.HaproxyStat, proxyStatsMap map[models.RoutingKey]ProxyStats) {
-	key, err := proxyKey(proxyStat.ProxyName)
-	if err == nil {
-		if _, ok := proxyStatsMap[key]; !ok {
-			proxyStatsMap[key] = ProxyStats{}
-		}
-		v := proxyStatsMap[key]
-		v.ConnectionTime += proxyStat.AverageConnectTimeMs
-		v.CurrentSessions += proxyStat.CurrentSessions
-		proxyStatsMap[key] = v
+	if proxyStat.ServerName != haproxy_client.BackendServerName {
+		return
 	}
+	proxyType, key, err := models.ParseProxyName(proxyStat.ProxyName)
+	if err != nil || proxyType != models.BackendProxy {
+		return
+	}
+
+	v := proxyStatsMap[key]
+	v.ConnectionTime += proxyStat.AverageConnectTimeMs
+	v.CurrentSessions += proxyStat.CurrentSessions
+	v.ConnectionErrors += proxyStat.ErrorConnecting
+	proxyStatsMap[key] = v
 }
 
-// proxyname i.e.  listen_cfg_9001, listen_cfg_9002
-func proxyKey(proxy string) (models.RoutingKey, error) {
-	routingKey := models.RoutingKey{}
+// ConvertServerStats reports the servers of each backend, using the routing
+// table to find the app instance behind the address HAProxy connects to.
+// Servers that are not part of a current route, such as unused server slots,
+// are left out.
+func ConvertServerStats(proxyStats haproxy_client.HaproxyStats, routingTable models.RoutingTable) map[ServerKey]ServerStats {
+	serverStatsMap := map[ServerKey]ServerStats{}
+	instances := map[models.RoutingKey]map[string]string{}
+
+	for _, proxyStat := range proxyStats {
+		if proxyStat.ServerName == haproxy_client.FrontendServerName || proxyStat.ServerName == haproxy_client.BackendServerName {
+			continue
+		}
+		proxyType, routingKey, err := models.ParseProxyName(proxyStat.ProxyName)
+		if err != nil || proxyType != models.BackendProxy {
+			continue
+		}
+
+		if _, ok := instances[routingKey]; !ok {
+			instances[routingKey] = instancesByAddress(routingTable.Entries[routingKey])
+		}
+		instanceID, ok := instances[routingKey][proxyStat.ServerAddress]
+		if !ok {
+			continue
+		}
 
-	proxyNameParts := strings.Split(proxy, "_")
-	if len(proxyNameParts) != 3 {
-		return routingKey, errors.New("not a valid proxy name")
+		serverStatsMap[ServerKey{RoutingKey: routingKey, Address: proxyStat.ServerAddress, InstanceID: instanceID}] = ServerStats{
+			Status:           proxyStat.Status,
+			Up:               isServerUp(proxyStat.Status),
+			ConnectionErrors: proxyStat.ErrorConnecting,
+			CurrentSessions:  proxyStat.CurrentSessions,
+			BytesIn:          proxyStat.BytesIn,
+			BytesOut:         proxyStat.BytesOut,
+			CheckFailures:    proxyStat.CheckFailures,
+		}
 	}
+	return serverStatsMap
+}
 
-	port, err := strconv.ParseUint(proxyNameParts[2], 10, 16)
-	if err != nil {
-		return routingKey, err
+// instancesByAddress maps each address HAProxy may connect to for a backend
+// to its instance ID. Servers are reached on their TLS port instead of their
+// port when backend TLS is enabled.
+func instancesByAddress(entry models.RoutingTableEntry) map[string]string {
+	instances := map[string]string{}
+	for backend := range entry.Backends {
+		if backend.Port > 0 {
+			instances[net.JoinHostPort(backend.Address, strconv.Itoa(int(backend.Port)))] = backend.InstanceID
+		}
+		if backend.TLSPort > 0 {
+			instances[net.JoinHostPort(backend.Address, strconv.Itoa(backend.TLSPort))] = backend.InstanceID
+		}
 	}
-	routingKey.Port = uint16(port)
-	return routingKey, nil
+	return instances
+}
+
+// HAProxy reports "no check" for servers without health checks, and suffixes
+// the status of servers moving between states, e.g. "UP 1/3".
+func isServerUp(status string) bool {
+	return strings.HasPrefix(status, "UP") || status == "no check" || status == "DRAIN"
 }
@@ -18,7 +18,8 @@ var _ = Describe("Metrics Converter", func() {
 		Context("when aggregating multiple proxies", func() {
 			BeforeEach(func() {
 				stats = haproxy_client.HaproxyStats{
-					{ProxyName: "fake_pxname1_9000",
+					{ProxyName: "backend_9000",
+						ServerName:           "BACKEND",
 						CurrentQueued:        10,
 						ErrorConnecting:      20,
 						AverageQueueTimeMs:   30,
@@ -26,7 +27,8 @@ var _ = Describe("Metrics Converter", func() {
 						CurrentSessions:      15,
 						AverageSessionTimeMs: 9,
 					},
-					{ProxyName: "fake_pxname2_9001",
+					{ProxyName: "backend_9001",
+						ServerName:           "BACKEND",
 						CurrentQueued:        20,
 						ErrorConnecting:      20,
 						AverageQueueTimeMs:   0,
@@ -34,7 +36,8 @@ var _ = Describe("Metrics Converter", func() {
 						CurrentSessions:      15,
 						AverageSessionTimeMs: 9,
 					},
-					{ProxyName: "fake_pxname2_9001",
+					{ProxyName: "backend_9001",
+						ServerName:           "BACKEND",
 						CurrentQueued:        20,
 						ErrorConnecting:      20,
 						AverageQueueTimeMs:   0,
@@ -65,21 +68,23 @@ var _ = Describe("Metrics Converter", func() {
 			It("gets stats per proxy", func() {
 				expectedProxyKey1 := models.RoutingKey{Port: 9000}
 				expectedProxyStats1 := metrics_reporter.ProxyStats{
-					ConnectionTime:  25,
-					CurrentSessions: 15,
+					ConnectionTime:   25,
+					CurrentSessions:  15,
+					ConnectionErrors: 20,
 				}
 				expectedProxyKey2 := models.RoutingKey{Port: 9001}
 				expectedProxyStats2 := metrics_reporter.ProxyStats{
-					ConnectionTime:  80,
-					CurrentSessions: 30,
+					ConnectionTime:   80,
+					CurrentSessions:  30,
+					ConnectionErrors: 40,
 				}
 				Expect(metrics.ProxyMetrics).Should(HaveKeyWithValue(expectedProxyKey1, expectedProxyStats1))
 				Expect(metrics.ProxyMetrics).Should(HaveKeyWithValue(expectedProxyKey2, expectedProxyStats2))
 			})
 
 			It("get error connection stats per proxy", func() {
-				Expect(metrics.RouteErrorMap).Should(HaveKeyWithValue("fake_pxname1_9000", uint64(20)))
-				Expect(metrics.RouteErrorMap).Should(HaveKeyWithValue("fake_pxname2_9001", uint64(40)))
+				Expect(metrics.RouteErrorMap).Should(HaveKeyWithValue("backend_9000", uint64(20)))
+				Expect(metrics.RouteErrorMap).Should(HaveKeyWithValue("backend_9001", uint64(40)))
 			})
 		})
 
@@ -87,7 +92,8 @@ var _ = Describe("Metrics Converter", func() {
 			BeforeEach(func() {
 				stats = haproxy_client.HaproxyStats{
 					{
-						ProxyName:            "fake_pxname1_9000",
+						ProxyName:            "backend_9000",
+						ServerName:           "BACKEND",
 						CurrentQueued:        10,
 						ErrorConnecting:      20,
 						AverageQueueTimeMs:   30,
@@ -96,7 +102,8 @@ var _ = Describe("Metrics Converter", func() {
 						AverageSessionTimeMs: 9,
 					},
 					{
-						ProxyName:            "fake_pxname2_9000",
+						ProxyName:            "backend_9000_sni.example.com",
+						ServerName:           "BACKEND",
 						CurrentQueued:        20,
 						ErrorConnecting:      20,
 						AverageQueueTimeMs:   0,
@@ -105,7 +112,8 @@ var _ = Describe("Metrics Converter", func() {
 						AverageSessionTimeMs: 9,
 					},
 					{
-						ProxyName:            "fake_pxname2_9000",
+						ProxyName:            "backend_9000_sni.example.com",
+						ServerName:           "BACKEND",
 						CurrentQueued:        20,
 						ErrorConnecting:      20,
 						AverageQueueTimeMs:   0,
@@ -117,14 +125,22 @@ var _ = Describe("Metrics Converter", func() {
 				metrics = metrics_reporter.Convert(stats)
 			})
 
-			It("aggregates current session per proxy", func() {
+			It("reports each sni route on the port separately", func() {
 				expectedProxyKey1 := models.RoutingKey{Port: 9000}
 				expectedProxyStats1 := metrics_reporter.ProxyStats{
-					ConnectionTime:  105,
-					CurrentSessions: 45,
+					ConnectionTime:   25,
+					CurrentSessions:  15,
+					ConnectionErrors: 20,
+				}
+				expectedProxyKey2 := models.RoutingKey{Port: 9000, SniHostname: "sni.example.com"}
+				expectedProxyStats2 := metrics_reporter.ProxyStats{
+					ConnectionTime:   80,
+					CurrentSessions:  30,
+					ConnectionErrors: 40,
 				}
 
 				Expect(metrics.ProxyMetrics).Should(HaveKeyWithValue(expectedProxyKey1, expectedProxyStats1))
+				Expect(metrics.ProxyMetrics).Should(HaveKeyWithValue(expectedProxyKey2, expectedProxyStats2))
 			})
 		})
 
@@ -132,7 +148,8 @@ var _ = Describe("Metrics Converter", func() {
 			BeforeEach(func() {
 				stats = haproxy_client.HaproxyStats{
 					{
-						ProxyName:            "fake_pxname1_BAD",
+						ProxyName:            "backend_BAD",
+						ServerName:           "BACKEND",
 						CurrentQueued:        10,
 						ErrorConnecting:      20,
 						AverageQueueTimeMs:   30,
@@ -141,7 +158,8 @@ var _ = Describe("Metrics Converter", func() {
 						AverageSessionTimeMs: 9,
 					},
 					{
-						ProxyName:            "fake_pxname2_9001",
+						ProxyName:            "backend_9001",
+						ServerName:           "BACKEND",
 						CurrentQueued:        20,
 						ErrorConnecting:      20,
 						AverageQueueTimeMs:   0,
@@ -158,8 +176,9 @@ var _ = Describe("Metrics Converter", func() {
 				expectedProxyKey1 := models.RoutingKey{Port: 9001}
 				expectedProxyKey2 := models.RoutingKey{Port: 0}
 				expectedProxyStats1 := metrics_reporter.ProxyStats{
-					ConnectionTime:  40,
-					CurrentSessions: 15,
+					ConnectionTime:   40,
+					CurrentSessions:  15,
+					ConnectionErrors: 20,
 				}
 
 				Expect(len(metrics.ProxyMetrics)).Should(Equal(1))
@@ -181,7 +200,8 @@ var _ = Describe("Metrics Converter", func() {
 						AverageSessionTimeMs: 9,
 					},
 					{
-						ProxyName:            "fake_pxname2_9001",
+						ProxyName:            "backend_9001",
+						ServerName:           "BACKEND",
 						CurrentQueued:        20,
 						ErrorConnecting:      20,
 						AverageQueueTimeMs:   0,
@@ -198,8 +218,9 @@ var _ = Describe("Metrics Converter", func() {
 				expectedProxyKey1 := models.RoutingKey{Port: 9001}
 				expectedProxyKey2 := models.RoutingKey{Port: 0}
 				expectedProxyStats1 := metrics_reporter.ProxyStats{
-					ConnectionTime:  40,
-					CurrentSessions: 15,
+					ConnectionTime:   40,
+					CurrentSessions:  15,
+					ConnectionErrors: 20,
 				}
 
 				Expect(len(metrics.ProxyMetrics)).Should(Equal(1))
@@ -208,6 +229,64 @@ var _ = Describe("Metrics Converter", func() {
 			})
 		})
 
+		Context("frontend, backend and server rows", func() {
+			BeforeEach(func() {
+				stats = haproxy_client.HaproxyStats{
+					{
+						ProxyName:            "frontend_9000",
+						ServerName:           "FRONTEND",
+						DeniedConnections:    3,
+						DeniedSessions:       1,
+						DeniedRequests:       6,
+						ErrorConnecting:      5,
+						AverageConnectTimeMs: 100,
+						CurrentSessions:      30,
+					},
+					{
+						ProxyName:            "backend_9000_my_app.example.com",
+						ServerName:           "BACKEND",
+						ErrorConnecting:      5,
+						AverageConnectTimeMs: 100,
+						CurrentSessions:      30,
+					},
+					{
+						ProxyName:            "backend_9000_my_app.example.com",
+						ServerName:           "server_10.0.0.1_8080",
+						ErrorConnecting:      5,
+						AverageConnectTimeMs: 100,
+						CurrentSessions:      30,
+					},
+				}
+
+				metrics = metrics_reporter.Convert(stats)
+			})
+
+			It("only reports per route stats from backend rows", func() {
+				Expect(metrics.ProxyMetrics).Should(HaveLen(1))
+				Expect(metrics.ProxyMetrics).Should(HaveKeyWithValue(
+					models.RoutingKey{Port: 9000, SniHostname: "my_app.example.com"},
+					metrics_reporter.ProxyStats{
+						ConnectionTime:   100,
+						CurrentSessions:  30,
+						ConnectionErrors: 5,
+					},
+				))
+			})
+
+			It("reports denied connections for each frontend", func() {
+				Expect(metrics.DeniedConnections).To(Equal(map[models.RoutingKey]uint64{{Port: 9000}: 4}))
+			})
+
+			It("reports rate limited connections for each frontend", func() {
+				Expect(metrics.RateLimitedConnections).To(Equal(map[models.RoutingKey]uint64{{Port: 9000}: 6}))
+			})
+
+			It("reports errors for every proxy by name", func() {
+				Expect(metrics.RouteErrorMap).Should(HaveKeyWithValue("frontend_9000", uint64(5)))
+				Expect(metrics.RouteErrorMap).Should(HaveKeyWithValue("backend_9000_my_app.example.com", uint64(10)))
+			})
+		})
+
 		Context("empty haproxy stats", func() {
 			BeforeEach(func() {
 				stats = haproxy_client.HaproxyStats{}
@@ -218,5 +297,99 @@ var _ = Describe("Metrics Converter", func() {
 				Expect(metrics).Should(BeNil())
 			})
 		})
+
+	})
+
+	Context("ConvertServerStats", func() {
+		var (
+			routingTable models.RoutingTable
+			serverStats  map[metrics_reporter.ServerKey]metrics_reporter.ServerStats
+		)
+
+		BeforeEach(func() {
+			routingTable = models.RoutingTable{Entries: map[models.RoutingKey]models.RoutingTableEntry{
+				{Port: 9000}: {Backends: map[models.BackendServerKey]models.BackendServerDetails{
+					{Address: "10.0.0.1", Port: 8080, InstanceID: "instance-1"}:                {},
+					{Address: "10.0.0.2", Port: 8080, TLSPort: 8443, InstanceID: "instance-2"}: {},
+				}},
+				{Port: 9000, SniHostname: "sni.example.com"}: {Backends: map[models.BackendServerKey]models.BackendServerDetails{
+					{Address: "fd00::1", Port: 8080, InstanceID: "instance-3"}: {},
+				}},
+			}}
+
+			stats = haproxy_client.HaproxyStats{
+				{ProxyName: "frontend_9000", ServerName: "FRONTEND", CurrentSessions: 100},
+				{ProxyName: "backend_9000", ServerName: "BACKEND", CurrentSessions: 100},
+				{
+					ProxyName:       "backend_9000",
+					ServerName:      "server_10.0.0.1_8080",
+					ServerAddress:   "10.0.0.1:8080",
+					Status:          "UP",
+					ErrorConnecting: 1,
+					CurrentSessions: 2,
+					BytesIn:         3,
+					BytesOut:        4,
+				},
+				{
+					ProxyName:       "backend_9000",
+					ServerName:      "server_10.0.0.2_8443",
+					ServerAddress:   "10.0.0.2:8443",
+					Status:          "DOWN",
+					ErrorConnecting: 5,
+					CheckFailures:   7,
+					CheckStatus:     "L4CON",
+				},
+				{
+					ProxyName:       "backend_9000_sni.example.com",
+					ServerName:      "slot_1",
+					ServerAddress:   "[fd00::1]:8080",
+					Status:          "no check",
+					CurrentSessions: 6,
+				},
+				{
+					ProxyName:     "backend_9000_sni.example.com",
+					ServerName:    "slot_2",
+					ServerAddress: "0.0.0.0:1",
+					Status:        "MAINT",
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			serverStats = metrics_reporter.ConvertServerStats(stats, routingTable)
+		})
+
+		It("reports the servers of each route by address and instance", func() {
+			Expect(serverStats).To(Equal(map[metrics_reporter.ServerKey]metrics_reporter.ServerStats{
+				{RoutingKey: models.RoutingKey{Port: 9000}, Address: "10.0.0.1:8080", InstanceID: "instance-1"}: {
+					Status:           "UP",
+					Up:               true,
+					ConnectionErrors: 1,
+					CurrentSessions:  2,
+					BytesIn:          3,
+					BytesOut:         4,
+				},
+				{RoutingKey: models.RoutingKey{Port: 9000}, Address: "10.0.0.2:8443", InstanceID: "instance-2"}: {
+					Status:           "DOWN",
+					ConnectionErrors: 5,
+					CheckFailures:    7,
+				},
+				{RoutingKey: models.RoutingKey{Port: 9000, SniHostname: "sni.example.com"}, Address: "[fd00::1]:8080", InstanceID: "instance-3"}: {
+					Status:          "no check",
+					Up:              true,
+					CurrentSessions: 6,
+				},
+			}))
+		})
+
+		Context("when a server is no longer in the routing table", func() {
+			BeforeEach(func() {
+				routingTable = models.RoutingTable{}
+			})
+
+			It("does not report it", func() {
+				Expect(serverStats).To(BeEmpty())
+			})
+		})
 	})
 })
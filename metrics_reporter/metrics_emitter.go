@@ -6,10 +6,21 @@ var (
 	averageQueueTimeMs           = DurationMs("AverageQueueTimeMs")
 	averageConnectTimeMs         = DurationMs("AverageConnectTimeMs")
 
-	connectionTime  = ProxyDurationMs("ConnectionTime")
-	currentSessions = ProxyValue("CurrentSessions")
+	connectionTime        = ProxyDurationMs("ConnectionTime")
+	currentSessions       = ProxyValue("CurrentSessions")
+	routeConnectionErrors = ProxyValue("ConnectionErrors")
 
 	proxyErrors = ProxyValue("ProxyConnectionErrors")
+
+	deniedConnections      = ProxyValue("DeniedConnections")
+	rateLimitedConnections = ProxyValue("RateLimitedConnections")
+
+	serverUp               = ProxyValue("ServerUp")
+	serverConnectionErrors = ProxyValue("ServerConnectionErrors")
+	serverCurrentSessions  = ProxyValue("ServerCurrentSessions")
+	serverBytesIn          = ProxyValue("ServerBytesIn")
+	serverBytesOut         = ProxyValue("ServerBytesOut")
+	serverCheckFailures    = ProxyValue("ServerCheckFailures")
 )
 
 type MetricsEmitter interface {
@@ -31,9 +42,28 @@ func (e *metricsEmitter) Emit(r *MetricsReport) {
 		for k, v := range r.ProxyMetrics {
 			connectionTime.Send(k.String(), v.ConnectionTime)
 			currentSessions.Send(k.String(), v.CurrentSessions)
+			routeConnectionErrors.Send(k.String(), v.ConnectionErrors)
 		}
 		for k, v := range r.RouteErrorMap {
 			proxyErrors.Send(k, v)
 		}
+		for k, v := range r.DeniedConnections {
+			deniedConnections.Send(k.String(), v)
+		}
+		for k, v := range r.RateLimitedConnections {
+			rateLimitedConnections.Send(k.String(), v)
+		}
+		for k, v := range r.ServerMetrics {
+			up := uint64(0)
+			if v.Up {
+				up = 1
+			}
+			serverUp.Send(k.String(), up)
+			serverConnectionErrors.Send(k.String(), v.ConnectionErrors)
+			serverCurrentSessions.Send(k.String(), v.CurrentSessions)
+			serverBytesIn.Send(k.String(), v.BytesIn)
+			serverBytesOut.Send(k.String(), v.BytesOut)
+			serverCheckFailures.Send(k.String(), v.CheckFailures)
+		}
 	}
 }
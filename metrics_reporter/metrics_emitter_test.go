@@ -36,18 +36,45 @@ var _ = Describe("MetricsEmitter", func() {
 					AverageConnectTimeMs:         1000,
 					ProxyMetrics: map[models.RoutingKey]metrics_reporter.ProxyStats{
 						models.RoutingKey{Port: 9000}: metrics_reporter.ProxyStats{
-							ConnectionTime:  10,
-							CurrentSessions: 50,
+							ConnectionTime:   10,
+							CurrentSessions:  50,
+							ConnectionErrors: 1,
 						},
-						models.RoutingKey{Port: 8000}: metrics_reporter.ProxyStats{
-							ConnectionTime:  100,
-							CurrentSessions: 500,
+						models.RoutingKey{Port: 8000, SniHostname: "sni.example.com"}: metrics_reporter.ProxyStats{
+							ConnectionTime:   100,
+							CurrentSessions:  500,
+							ConnectionErrors: 2,
+						},
+						models.RoutingKey{Port: 8000, SniHostname: "*.db.example.com"}: metrics_reporter.ProxyStats{
+							ConnectionTime:   1000,
+							CurrentSessions:  5000,
+							ConnectionErrors: 3,
 						},
 					},
 					RouteErrorMap: map[string]uint64{
 						"proxy1": 1,
 						"proxy2": 2,
 					},
+					DeniedConnections: map[models.RoutingKey]uint64{
+						{Port: 9000}: 7,
+					},
+					RateLimitedConnections: map[models.RoutingKey]uint64{
+						{Port: 9000}: 8,
+					},
+					ServerMetrics: map[metrics_reporter.ServerKey]metrics_reporter.ServerStats{
+						{RoutingKey: models.RoutingKey{Port: 9000}, Address: "10.0.0.1:8080", InstanceID: "instance-1"}: {
+							Status:           "UP",
+							Up:               true,
+							ConnectionErrors: 1,
+							CurrentSessions:  2,
+							BytesIn:          3,
+							BytesOut:         4,
+						},
+						{RoutingKey: models.RoutingKey{Port: 9000}, Address: "10.0.0.2:8080", InstanceID: "instance-2"}: {
+							Status:        "DOWN",
+							CheckFailures: 5,
+						},
+					},
 				}
 			})
 
@@ -79,22 +106,40 @@ var _ = Describe("MetricsEmitter", func() {
 				}).Should(Equal(fake.Metric{Value: float64(1000), Unit: "ms"}))
 			})
 
-			It("emits ConnectionTime metrics for each port", func() {
+			It("emits ConnectionTime metrics for each route", func() {
 				Eventually(func() fake.Metric {
 					return sender.GetValue("9000.ConnectionTime")
 				}).Should(Equal(fake.Metric{Value: float64(10), Unit: "ms"}))
 				Eventually(func() fake.Metric {
-					return sender.GetValue("8000.ConnectionTime")
+					return sender.GetValue("8000_sni_example_com.ConnectionTime")
 				}).Should(Equal(fake.Metric{Value: float64(100), Unit: "ms"}))
+				Eventually(func() fake.Metric {
+					return sender.GetValue("8000_wildcard:db_example_com.ConnectionTime")
+				}).Should(Equal(fake.Metric{Value: float64(1000), Unit: "ms"}))
 			})
 
-			It("emits CurrentSessions metrics for each port", func() {
+			It("emits CurrentSessions metrics for each route", func() {
 				Eventually(func() fake.Metric {
 					return sender.GetValue("9000.CurrentSessions")
 				}).Should(Equal(fake.Metric{Value: float64(50), Unit: "Metric"}))
 				Eventually(func() fake.Metric {
-					return sender.GetValue("8000.CurrentSessions")
+					return sender.GetValue("8000_sni_example_com.CurrentSessions")
 				}).Should(Equal(fake.Metric{Value: float64(500), Unit: "Metric"}))
+				Eventually(func() fake.Metric {
+					return sender.GetValue("8000_wildcard:db_example_com.CurrentSessions")
+				}).Should(Equal(fake.Metric{Value: float64(5000), Unit: "Metric"}))
+			})
+
+			It("emits ConnectionErrors metrics for each route", func() {
+				Eventually(func() fake.Metric {
+					return sender.GetValue("9000.ConnectionErrors")
+				}).Should(Equal(fake.Metric{Value: float64(1), Unit: "Metric"}))
+				Eventually(func() fake.Metric {
+					return sender.GetValue("8000_sni_example_com.ConnectionErrors")
+				}).Should(Equal(fake.Metric{Value: float64(2), Unit: "Metric"}))
+				Eventually(func() fake.Metric {
+					return sender.GetValue("8000_wildcard:db_example_com.ConnectionErrors")
+				}).Should(Equal(fake.Metric{Value: float64(3), Unit: "Metric"}))
 			})
 
 			It("emits Errors metrics for each proxy", func() {
@@ -105,6 +150,42 @@ var _ = Describe("MetricsEmitter", func() {
 					return sender.GetValue("proxy2.ProxyConnectionErrors")
 				}).Should(Equal(fake.Metric{Value: float64(2), Unit: "Metric"}))
 			})
+
+			It("emits DeniedConnections metrics for each port", func() {
+				Eventually(func() fake.Metric {
+					return sender.GetValue("9000.DeniedConnections")
+				}).Should(Equal(fake.Metric{Value: float64(7), Unit: "Metric"}))
+			})
+
+			It("emits RateLimitedConnections metrics for each port", func() {
+				Eventually(func() fake.Metric {
+					return sender.GetValue("9000.RateLimitedConnections")
+				}).Should(Equal(fake.Metric{Value: float64(8), Unit: "Metric"}))
+			})
+
+			It("emits metrics for each server", func() {
+				Eventually(func() fake.Metric {
+					return sender.GetValue("9000_10.0.0.1:8080_instance-1.ServerUp")
+				}).Should(Equal(fake.Metric{Value: float64(1), Unit: "Metric"}))
+				Eventually(func() fake.Metric {
+					return sender.GetValue("9000_10.0.0.1:8080_instance-1.ServerConnectionErrors")
+				}).Should(Equal(fake.Metric{Value: float64(1), Unit: "Metric"}))
+				Eventually(func() fake.Metric {
+					return sender.GetValue("9000_10.0.0.1:8080_instance-1.ServerCurrentSessions")
+				}).Should(Equal(fake.Metric{Value: float64(2), Unit: "Metric"}))
+				Eventually(func() fake.Metric {
+					return sender.GetValue("9000_10.0.0.1:8080_instance-1.ServerBytesIn")
+				}).Should(Equal(fake.Metric{Value: float64(3), Unit: "Metric"}))
+				Eventually(func() fake.Metric {
+					return sender.GetValue("9000_10.0.0.1:8080_instance-1.ServerBytesOut")
+				}).Should(Equal(fake.Metric{Value: float64(4), Unit: "Metric"}))
+				Eventually(func() fake.Metric {
+					return sender.GetValue("9000_10.0.0.2:8080_instance-2.ServerUp")
+				}).Should(Equal(fake.Metric{Value: float64(0), Unit: "Metric"}))
+				Eventually(func() fake.Metric {
+					return sender.GetValue("9000_10.0.0.2:8080_instance-2.ServerCheckFailures")
+				}).Should(Equal(fake.Metric{Value: float64(5), Unit: "Metric"}))
+			})
 		})
 
 		Context("when nil MetricsReport is passed", func() {
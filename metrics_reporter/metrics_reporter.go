@@ -1,6 +1,7 @@
 package metrics_reporter
 
 import (
+	"fmt"
 	"os"
 	"syscall"
 	"time"
@@ -18,6 +19,11 @@ type MetricsReport struct {
 	AverageConnectTimeMs         uint64
 	ProxyMetrics                 map[models.RoutingKey]ProxyStats
 	RouteErrorMap                map[string]uint64
+	ServerMetrics                map[ServerKey]ServerStats
+	// Connections rejected by access control rules and by rate limits, by the
+	// port of the frontend
+	DeniedConnections      map[models.RoutingKey]uint64
+	RateLimitedConnections map[models.RoutingKey]uint64
 }
 
 type RouteErrorReport struct {
@@ -25,25 +31,58 @@ type RouteErrorReport struct {
 }
 
 type ProxyStats struct {
-	ConnectionTime  uint64
-	CurrentSessions uint64
+	ConnectionTime   uint64
+	CurrentSessions  uint64
+	ConnectionErrors uint64
+}
+
+// ServerKey identifies a backend server of a route by the address HAProxy
+// connects to and the app instance it belongs to.
+type ServerKey struct {
+	RoutingKey models.RoutingKey
+	Address    string
+	InstanceID string
+}
+
+func (k ServerKey) String() string {
+	if k.InstanceID == "" {
+		return fmt.Sprintf("%s_%s", k.RoutingKey, k.Address)
+	}
+	return fmt.Sprintf("%s_%s_%s", k.RoutingKey, k.Address, k.InstanceID)
+}
+
+type ServerStats struct {
+	Status           string
+	Up               bool
+	ConnectionErrors uint64
+	CurrentSessions  uint64
+	BytesIn          uint64
+	BytesOut         uint64
+	CheckFailures    uint64
+}
+
+//go:generate counterfeiter -o fakes/fake_routing_table_source.go . RoutingTableSource
+type RoutingTableSource interface {
+	RoutingTable() models.RoutingTable
 }
 
 type MetricsReporter struct {
-	clock          clock.Clock
-	emitInterval   time.Duration
-	haproxyClient  haproxy_client.HaproxyClient
-	metricsEmitter MetricsEmitter
-	logger         lager.Logger
+	clock              clock.Clock
+	emitInterval       time.Duration
+	haproxyClient      haproxy_client.HaproxyClient
+	routingTableSource RoutingTableSource
+	metricsEmitter     MetricsEmitter
+	logger             lager.Logger
 }
 
-func NewMetricsReporter(clock clock.Clock, haproxyClient haproxy_client.HaproxyClient, metricsEmitter MetricsEmitter, interval time.Duration, logger lager.Logger) *MetricsReporter {
+func NewMetricsReporter(clock clock.Clock, haproxyClient haproxy_client.HaproxyClient, routingTableSource RoutingTableSource, metricsEmitter MetricsEmitter, interval time.Duration, logger lager.Logger) *MetricsReporter {
 	return &MetricsReporter{
-		clock:          clock,
-		haproxyClient:  haproxyClient,
-		metricsEmitter: metricsEmitter,
-		emitInterval:   interval,
-		logger:         logger.Session("metrics-reporter"),
+		clock:              clock,
+		haproxyClient:      haproxyClient,
+		routingTableSource: routingTableSource,
+		metricsEmitter:     metricsEmitter,
+		emitInterval:       interval,
+		logger:             logger.Session("metrics-reporter"),
 	}
 }
 
@@ -71,6 +110,7 @@ func (r *MetricsReporter) emitStats() {
 	if len(stats) > 0 {
 		// convert to report
 		report := Convert(stats)
+		report.ServerMetrics = ConvertServerStats(stats, r.routingTableSource.RoutingTable())
 
 		// emit to firehose
 		r.metricsEmitter.Emit(report)
@@ -9,6 +9,7 @@ import (
 	emitter_fakes "code.cloudfoundry.org/cf-tcp-router/metrics_reporter/fakes"
 	"code.cloudfoundry.org/cf-tcp-router/metrics_reporter/haproxy_client"
 	haproxy_fakes "code.cloudfoundry.org/cf-tcp-router/metrics_reporter/haproxy_client/fakes"
+	"code.cloudfoundry.org/cf-tcp-router/models"
 	"code.cloudfoundry.org/clock/fakeclock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -17,20 +18,22 @@ import (
 
 var _ = Describe("Metrics Reporter", func() {
 	var (
-		fakeClient      *haproxy_fakes.FakeHaproxyClient
-		fakeEmitter     *emitter_fakes.FakeMetricsEmitter
-		metricsReporter *metrics_reporter.MetricsReporter
-		clock           *fakeclock.FakeClock
-		process         ifrit.Process
-		syncInterval    time.Duration
+		fakeClient       *haproxy_fakes.FakeHaproxyClient
+		fakeEmitter      *emitter_fakes.FakeMetricsEmitter
+		fakeRoutingTable *emitter_fakes.FakeRoutingTableSource
+		metricsReporter  *metrics_reporter.MetricsReporter
+		clock            *fakeclock.FakeClock
+		process          ifrit.Process
+		syncInterval     time.Duration
 	)
 
 	BeforeEach(func() {
 		fakeClient = &haproxy_fakes.FakeHaproxyClient{}
 		fakeEmitter = &emitter_fakes.FakeMetricsEmitter{}
+		fakeRoutingTable = &emitter_fakes.FakeRoutingTableSource{}
 		clock = fakeclock.NewFakeClock(time.Now())
 		syncInterval = 1 * time.Second
-		metricsReporter = metrics_reporter.NewMetricsReporter(clock, fakeClient, fakeEmitter, syncInterval, logger)
+		metricsReporter = metrics_reporter.NewMetricsReporter(clock, fakeClient, fakeRoutingTable, fakeEmitter, syncInterval, logger)
 	})
 
 	Context("on specified interval", func() {
@@ -39,7 +42,8 @@ var _ = Describe("Metrics Reporter", func() {
 				fakeClient.GetStatsStub = func() haproxy_client.HaproxyStats {
 					return haproxy_client.HaproxyStats{
 						{
-							ProxyName:            "fake_pxname1_9000",
+							ProxyName:            "backend_9000",
+							ServerName:           "BACKEND",
 							CurrentQueued:        10,
 							ErrorConnecting:      20,
 							AverageQueueTimeMs:   30,
@@ -47,6 +51,13 @@ var _ = Describe("Metrics Reporter", func() {
 							CurrentSessions:      15,
 							AverageSessionTimeMs: 9,
 						},
+						{
+							ProxyName:       "backend_9000",
+							ServerName:      "server_10.0.0.1_8080",
+							ServerAddress:   "10.0.0.1:8080",
+							Status:          "UP",
+							CurrentSessions: 3,
+						},
 					}
 				}
 			})
@@ -73,6 +84,23 @@ var _ = Describe("Metrics Reporter", func() {
 				Eventually(fakeClient.GetStatsCallCount).Should(Equal(2))
 				Eventually(fakeEmitter.EmitCallCount).Should(Equal(2))
 			})
+
+			It("includes server metrics for the servers in the routing table", func() {
+				fakeRoutingTable.RoutingTableReturns(models.RoutingTable{Entries: map[models.RoutingKey]models.RoutingTableEntry{
+					{Port: 9000}: {Backends: map[models.BackendServerKey]models.BackendServerDetails{
+						{Address: "10.0.0.1", Port: 8080, InstanceID: "instance-1"}: {},
+					}},
+				}})
+
+				process = ifrit.Invoke(metricsReporter)
+				clock.Increment(syncInterval + 100*time.Millisecond)
+
+				Eventually(fakeEmitter.EmitCallCount).Should(Equal(1))
+				Expect(fakeEmitter.EmitArgsForCall(0).ServerMetrics).To(HaveKeyWithValue(
+					metrics_reporter.ServerKey{RoutingKey: models.RoutingKey{Port: 9000}, Address: "10.0.0.1:8080", InstanceID: "instance-1"},
+					metrics_reporter.ServerStats{Status: "UP", Up: true, CurrentSessions: 3},
+				))
+			})
 		})
 		Context("when haproxy client returns no stats data", func() {
 			BeforeEach(func() {
@@ -0,0 +1,238 @@
+package metrics_reporter
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"code.cloudfoundry.org/cf-tcp-router/models"
+)
+
+const (
+	PrometheusMetricsPath = "/metrics"
+	prometheusNamespace   = "tcp_router"
+)
+
+// PrometheusEmitter keeps the latest metrics report and every metric sent
+// through the metric types in memory, and serves them in the Prometheus text
+// exposition format. It is used in place of dropsonde when there is no local
+// metron agent to send metrics to.
+type PrometheusEmitter struct {
+	lock        *sync.Mutex
+	report      *MetricsReport
+	gauges      map[string]float64
+	proxyGauges map[string]map[string]float64
+	counters    map[string]uint64
+}
+
+func NewPrometheusEmitter() *PrometheusEmitter {
+	return &PrometheusEmitter{
+		lock:        new(sync.Mutex),
+		gauges:      map[string]float64{},
+		proxyGauges: map[string]map[string]float64{},
+		counters:    map[string]uint64{},
+	}
+}
+
+// Emit replaces the previous report, so routes that no longer exist stop
+// being exposed.
+func (e *PrometheusEmitter) Emit(r *MetricsReport) {
+	if r == nil {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.report = r
+}
+
+func (e *PrometheusEmitter) SendValue(name string, value float64, unit string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.gauges[prometheusName(name, unit)] = value
+}
+
+func (e *PrometheusEmitter) SendProxyValue(proxyName string, name string, value float64, unit string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	metricName := prometheusName(name, unit)
+	if _, ok := e.proxyGauges[metricName]; !ok {
+		e.proxyGauges[metricName] = map[string]float64{}
+	}
+	e.proxyGauges[metricName][proxyName] = value
+}
+
+func (e *PrometheusEmitter) AddToCounter(name string, delta uint64) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.counters[prometheusName(name, "")+"_total"] += delta
+}
+
+func (e *PrometheusEmitter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.write(w)
+}
+
+func (e *PrometheusEmitter) write(w io.Writer) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	gauges := map[string]float64{}
+	for name, value := range e.gauges {
+		gauges[name] = value
+	}
+	labelledGauges := map[string][]sample{}
+	for name, values := range e.proxyGauges {
+		for proxyName, value := range values {
+			labelledGauges[name] = append(labelledGauges[name], sample{labels: [][2]string{{"proxy", proxyName}}, value: value})
+		}
+	}
+
+	if r := e.report; r != nil {
+		gauges[prometheusName(string(totalCurrentQueuedRequests), "Metric")] = float64(r.TotalCurrentQueuedRequests)
+		gauges[prometheusName(string(totalBackendConnectionErrors), "Metric")] = float64(r.TotalBackendConnectionErrors)
+		gauges[prometheusName(string(averageQueueTimeMs), "ms")] = float64(r.AverageQueueTimeMs)
+		gauges[prometheusName(string(averageConnectTimeMs), "ms")] = float64(r.AverageConnectTimeMs)
+
+		connectionTimeName := prometheusName(string(connectionTime), "ms")
+		currentSessionsName := prometheusName(string(currentSessions), "Metric")
+		connectionErrorsName := prometheusName(string(routeConnectionErrors), "Metric")
+		for key, stats := range r.ProxyMetrics {
+			labels := routingKeyLabels(key)
+			labelledGauges[connectionTimeName] = append(labelledGauges[connectionTimeName], sample{labels: labels, value: float64(stats.ConnectionTime)})
+			labelledGauges[currentSessionsName] = append(labelledGauges[currentSessionsName], sample{labels: labels, value: float64(stats.CurrentSessions)})
+			labelledGauges[connectionErrorsName] = append(labelledGauges[connectionErrorsName], sample{labels: labels, value: float64(stats.ConnectionErrors)})
+		}
+
+		proxyErrorsName := prometheusName(string(proxyErrors), "Metric")
+		for proxyName, errors := range r.RouteErrorMap {
+			labelledGauges[proxyErrorsName] = append(labelledGauges[proxyErrorsName], sample{labels: [][2]string{{"proxy", proxyName}}, value: float64(errors)})
+		}
+
+		deniedConnectionsName := prometheusName(string(deniedConnections), "Metric")
+		for key, denied := range r.DeniedConnections {
+			labels := [][2]string{{"port", strconv.Itoa(int(key.Port))}}
+			labelledGauges[deniedConnectionsName] = append(labelledGauges[deniedConnectionsName], sample{labels: labels, value: float64(denied)})
+		}
+		rateLimitedConnectionsName := prometheusName(string(rateLimitedConnections), "Metric")
+		for key, rateLimited := range r.RateLimitedConnections {
+			labels := [][2]string{{"port", strconv.Itoa(int(key.Port))}}
+			labelledGauges[rateLimitedConnectionsName] = append(labelledGauges[rateLimitedConnectionsName], sample{labels: labels, value: float64(rateLimited)})
+		}
+
+		for key, stats := range r.ServerMetrics {
+			labels := serverKeyLabels(key)
+			up := float64(0)
+			if stats.Up {
+				up = 1
+			}
+			for name, value := range map[ProxyValue]float64{
+				serverUp:               up,
+				serverConnectionErrors: float64(stats.ConnectionErrors),
+				serverCurrentSessions:  float64(stats.CurrentSessions),
+				serverBytesIn:          float64(stats.BytesIn),
+				serverBytesOut:         float64(stats.BytesOut),
+				serverCheckFailures:    float64(stats.CheckFailures),
+			} {
+				metricName := prometheusName(string(name), "Metric")
+				labelledGauges[metricName] = append(labelledGauges[metricName], sample{labels: labels, value: value})
+			}
+		}
+	}
+
+	for name, value := range gauges {
+		labelledGauges[name] = append(labelledGauges[name], sample{value: value})
+	}
+	for _, name := range sortedKeys(labelledGauges) {
+		writeMetric(w, name, "gauge", labelledGauges[name])
+	}
+
+	counters := map[string][]sample{}
+	for name, value := range e.counters {
+		counters[name] = []sample{{value: float64(value)}}
+	}
+	for _, name := range sortedKeys(counters) {
+		writeMetric(w, name, "counter", counters[name])
+	}
+}
+
+type sample struct {
+	labels [][2]string
+	value  float64
+}
+
+func (s sample) String() string {
+	if len(s.labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(s.labels))
+	for _, label := range s.labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", label[0], label[1]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func writeMetric(w io.Writer, name string, metricType string, samples []sample) {
+	sort.Slice(samples, func(i, j int) bool { return samples[i].String() < samples[j].String() })
+
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, metricType)
+	for _, s := range samples {
+		fmt.Fprintf(w, "%s%s %s\n", name, s, strconv.FormatFloat(s.value, 'g', -1, 64))
+	}
+}
+
+func routingKeyLabels(key models.RoutingKey) [][2]string {
+	return [][2]string{
+		{"port", strconv.Itoa(int(key.Port))},
+		{"sni_hostname", string(key.SniHostname)},
+	}
+}
+
+func serverKeyLabels(key ServerKey) [][2]string {
+	return append(routingKeyLabels(key.RoutingKey),
+		[2]string{"address", key.Address},
+		[2]string{"instance_id", key.InstanceID},
+	)
+}
+
+// prometheusName converts a metric name such as "AverageQueueTimeMs" to
+// "tcp_router_average_queue_time_ms", adding a unit suffix for durations
+// that do not already carry one.
+func prometheusName(name string, unit string) string {
+	var b strings.Builder
+	b.WriteString(prometheusNamespace)
+	b.WriteString("_")
+
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteString("_")
+			}
+			r = unicode.ToLower(r)
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			r = '_'
+		}
+		b.WriteRune(r)
+	}
+
+	metricName := b.String()
+	if unit == "ms" && !strings.HasSuffix(metricName, "_ms") {
+		metricName += "_ms"
+	}
+	return metricName
+}
+
+func sortedKeys(m map[string][]sample) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,136 @@
+package metrics_reporter_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/cf-tcp-router/metrics_reporter"
+	"code.cloudfoundry.org/cf-tcp-router/models"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PrometheusEmitter", func() {
+	var (
+		emitter *metrics_reporter.PrometheusEmitter
+	)
+
+	scrape := func() string {
+		recorder := httptest.NewRecorder()
+		emitter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, metrics_reporter.PrometheusMetricsPath, nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Content-Type")).To(HavePrefix("text/plain; version=0.0.4"))
+		return recorder.Body.String()
+	}
+
+	BeforeEach(func() {
+		emitter = metrics_reporter.NewPrometheusEmitter()
+	})
+
+	Context("when nothing has been emitted", func() {
+		It("serves an empty response", func() {
+			Expect(scrape()).To(BeEmpty())
+		})
+	})
+
+	Describe("Emit", func() {
+		var metricsReport metrics_reporter.MetricsReport
+
+		BeforeEach(func() {
+			metricsReport = metrics_reporter.MetricsReport{
+				TotalCurrentQueuedRequests:   10,
+				TotalBackendConnectionErrors: 3,
+				AverageQueueTimeMs:           100,
+				AverageConnectTimeMs:         1000,
+				ProxyMetrics: map[models.RoutingKey]metrics_reporter.ProxyStats{
+					{Port: 9000}: {ConnectionTime: 10, CurrentSessions: 50, ConnectionErrors: 1},
+					{Port: 8000, SniHostname: "sni.example.com"}: {ConnectionTime: 100, CurrentSessions: 500, ConnectionErrors: 2},
+				},
+				RouteErrorMap: map[string]uint64{
+					"proxy1": 1,
+				},
+			}
+		})
+
+		It("exposes the report as gauges", func() {
+			emitter.Emit(&metricsReport)
+
+			Expect(scrape()).To(Equal(`# TYPE tcp_router_average_connect_time_ms gauge
+tcp_router_average_connect_time_ms 1000
+# TYPE tcp_router_average_queue_time_ms gauge
+tcp_router_average_queue_time_ms 100
+# TYPE tcp_router_connection_errors gauge
+tcp_router_connection_errors{port="8000",sni_hostname="sni.example.com"} 2
+tcp_router_connection_errors{port="9000",sni_hostname=""} 1
+# TYPE tcp_router_connection_time_ms gauge
+tcp_router_connection_time_ms{port="8000",sni_hostname="sni.example.com"} 100
+tcp_router_connection_time_ms{port="9000",sni_hostname=""} 10
+# TYPE tcp_router_current_sessions gauge
+tcp_router_current_sessions{port="8000",sni_hostname="sni.example.com"} 500
+tcp_router_current_sessions{port="9000",sni_hostname=""} 50
+# TYPE tcp_router_proxy_connection_errors gauge
+tcp_router_proxy_connection_errors{proxy="proxy1"} 1
+# TYPE tcp_router_total_backend_connection_errors gauge
+tcp_router_total_backend_connection_errors 3
+# TYPE tcp_router_total_current_queued_requests gauge
+tcp_router_total_current_queued_requests 10
+`))
+		})
+
+		It("labels server metrics with the route, address and instance", func() {
+			metricsReport.ServerMetrics = map[metrics_reporter.ServerKey]metrics_reporter.ServerStats{
+				{RoutingKey: models.RoutingKey{Port: 9000}, Address: "10.0.0.1:8080", InstanceID: "instance-1"}: {Up: true, CurrentSessions: 2},
+			}
+			emitter.Emit(&metricsReport)
+
+			metrics := scrape()
+			Expect(metrics).To(ContainSubstring(`tcp_router_server_up{port="9000",sni_hostname="",address="10.0.0.1:8080",instance_id="instance-1"} 1` + "\n"))
+			Expect(metrics).To(ContainSubstring(`tcp_router_server_current_sessions{port="9000",sni_hostname="",address="10.0.0.1:8080",instance_id="instance-1"} 2` + "\n"))
+			Expect(metrics).To(ContainSubstring(`tcp_router_server_bytes_out{port="9000",sni_hostname="",address="10.0.0.1:8080",instance_id="instance-1"} 0` + "\n"))
+		})
+
+		It("labels denied connections with the port", func() {
+			metricsReport.DeniedConnections = map[models.RoutingKey]uint64{{Port: 9000}: 7}
+			emitter.Emit(&metricsReport)
+
+			Expect(scrape()).To(ContainSubstring("# TYPE tcp_router_denied_connections gauge\ntcp_router_denied_connections{port=\"9000\"} 7\n"))
+		})
+
+		It("drops routes that are missing from the latest report", func() {
+			emitter.Emit(&metricsReport)
+			emitter.Emit(&metrics_reporter.MetricsReport{ProxyMetrics: map[models.RoutingKey]metrics_reporter.ProxyStats{
+				{Port: 8000}: {ConnectionTime: 100, CurrentSessions: 500},
+			}})
+
+			Expect(scrape()).NotTo(ContainSubstring(`port="9000"`))
+		})
+
+		It("ignores nil reports", func() {
+			emitter.Emit(&metricsReport)
+			emitter.Emit(nil)
+
+			Expect(scrape()).To(ContainSubstring("tcp_router_total_current_queued_requests 10\n"))
+		})
+	})
+
+	Describe("as a metric sink", func() {
+		It("exposes values, durations and counters sent by the router", func() {
+			emitter.SendValue("RoutingTableSize", 12, "Metric")
+			emitter.SendValue("SyncDuration", 250, "ms")
+			emitter.SendProxyValue("backend_9000", "CurrentSessions", 4, "Metric")
+			emitter.AddToCounter("Reloads", 1)
+			emitter.AddToCounter("Reloads", 2)
+
+			Expect(scrape()).To(Equal(`# TYPE tcp_router_current_sessions gauge
+tcp_router_current_sessions{proxy="backend_9000"} 4
+# TYPE tcp_router_routing_table_size gauge
+tcp_router_routing_table_size 12
+# TYPE tcp_router_sync_duration_ms gauge
+tcp_router_sync_duration_ms 250
+# TYPE tcp_router_reloads_total counter
+tcp_router_reloads_total 3
+`))
+		})
+	})
+})
@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"net"
 	"regexp"
 	"sort"
 	"strings"
@@ -24,7 +25,7 @@ func NewHAProxyConfig(routingTable RoutingTable, logger lager.Logger) HAProxyCon
 			continue
 		}
 
-		if routingKey.SniHostname != "" && !isValidDNSName(string(routingKey.SniHostname)) {
+		if routingKey.SniHostname != "" && !isValidSniHostname(routingKey.SniHostname) {
 			logError(logger, "frontend_configuration.sni_hostname", routingKey, routingKey.SniHostname)
 			continue
 		}
@@ -38,7 +39,7 @@ func NewHAProxyConfig(routingTable RoutingTable, logger lager.Logger) HAProxyCon
 				continue
 			}
 
-			if backendKey.Address == "" || !isValidDNSName(backendKey.Address) {
+			if !isValidAddress(backendKey.Address) {
 				logError(logger, "backend_configuration.address", routingKey, backendKey.Address)
 				continue
 			}
@@ -86,9 +87,29 @@ func (hf HAProxyFrontend) ContainsSNIRoutes() bool {
 	return false
 }
 
+// isValidAddress accepts IPv4 and IPv6 literals as well as DNS names
+func isValidAddress(address string) bool {
+	if address == "" {
+		return false
+	}
+	if net.ParseIP(address) != nil {
+		return true
+	}
+	return isValidDNSName(address)
+}
+
 // Stolen with gratitude from https://github.com/asaskevich/govalidator/blob/v11/patterns.go#L33
 var validDNSNameRegexp = regexp.MustCompile(`^([a-zA-Z0-9_]{1}[a-zA-Z0-9_-]{0,62}){1}(\.[a-zA-Z0-9_]{1}[a-zA-Z0-9_-]{0,62})*[\._]?$`)
 
+// isValidSniHostname accepts DNS names, optionally with a wildcard as their
+// leftmost label
+func isValidSniHostname(hostname SniHostname) bool {
+	if hostname.IsWildcard() {
+		return isValidDNSName(strings.TrimPrefix(string(hostname), WildcardPrefix))
+	}
+	return isValidDNSName(string(hostname))
+}
+
 func isValidDNSName(hostname string) bool {
 	if len(strings.Replace(hostname, ".", "", -1)) > 255 {
 		return false
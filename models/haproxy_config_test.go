@@ -59,6 +59,24 @@ var _ = Describe("HAProxyConfig", func() {
 				})
 			})
 
+			Context("because it contains an invalid wildcard SNI hostname", func() {
+				It("retains only valid frontends", func() {
+					routingTable.Entries[RoutingKey{Port: 80, SniHostname: "*.db.example.com"}] = validRoutingTableEntry
+					routingTable.Entries[RoutingKey{Port: 90, SniHostname: "*db.example.com"}] = validRoutingTableEntry
+					routingTable.Entries[RoutingKey{Port: 100, SniHostname: "a.*.example.com"}] = validRoutingTableEntry
+					routingTable.Entries[RoutingKey{Port: 110, SniHostname: "*"}] = validRoutingTableEntry
+					routingTable.Entries[RoutingKey{Port: 120, SniHostname: "*.*.example.com"}] = validRoutingTableEntry
+
+					Expect(NewHAProxyConfig(routingTable, logger)).To(Equal(HAProxyConfig{
+						80: {
+							"*.db.example.com": {
+								{Address: "valid-host.internal", Port: 1111},
+							},
+						},
+					}))
+				})
+			})
+
 			Context("because it contains no backends", func() {
 				It("retains only valid frontends", func() {
 					routingTable.Entries[RoutingKey{Port: 80}] = validRoutingTableEntry
@@ -96,6 +114,28 @@ var _ = Describe("HAProxyConfig", func() {
 					})
 				})
 
+				Context("because it contains a malformed IPv6 address", func() {
+					It("retains only valid backends", func() {
+						routingTable.Entries[RoutingKey{Port: 80}] = RoutingTableEntry{
+							Backends: map[BackendServerKey]BackendServerDetails{
+								BackendServerKey{Address: "fd00::1", Port: 1111}:   {},
+								BackendServerKey{Address: "[fd00::2]", Port: 2222}: {},
+								BackendServerKey{Address: "fd00:::3", Port: 3333}:  {},
+								BackendServerKey{Address: "10.0.0.1", Port: 4444}:  {},
+							},
+						}
+
+						Expect(NewHAProxyConfig(routingTable, logger)).To(Equal(HAProxyConfig{
+							80: {
+								"": {
+									{Address: "10.0.0.1", Port: 4444},
+									{Address: "fd00::1", Port: 1111},
+								},
+							},
+						}))
+					})
+				})
+
 				Context("because it contains an invalid port", func() {
 					It("retains only valid backends", func() {
 						routingTable.Entries[RoutingKey{Port: 80}] = RoutingTableEntry{
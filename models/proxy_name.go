@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HAProxy proxies are named after the routes they serve, so that stats
+// reported by HAProxy can be attributed to a RoutingKey:
+//
+//	frontend_<port>                 one per inbound port
+//	backend_<port>                  the non-SNI route on a port
+//	backend_<port>_<sni hostname>   an SNI route on a port
+//
+// HAProxy does not allow "*" in proxy names, so the wildcard of a wildcard
+// SNI hostname is spelled "wildcard:", which no DNS name can contain.
+const (
+	FrontendProxyPrefix = "frontend_"
+	BackendProxyPrefix  = "backend_"
+
+	wildcardProxyNamePrefix = "wildcard:"
+)
+
+type ProxyType int
+
+const (
+	FrontendProxy ProxyType = iota
+	BackendProxy
+)
+
+func FrontendProxyName(port HAProxyInboundPort) string {
+	return fmt.Sprintf("%s%d", FrontendProxyPrefix, port)
+}
+
+func BackendProxyName(port HAProxyInboundPort, hostname SniHostname) string {
+	if hostname == "" {
+		return fmt.Sprintf("%s%d", BackendProxyPrefix, port)
+	}
+	if hostname.IsWildcard() {
+		return fmt.Sprintf("%s%d_%s%s", BackendProxyPrefix, port, wildcardProxyNamePrefix, strings.TrimPrefix(string(hostname), WildcardPrefix))
+	}
+	return fmt.Sprintf("%s%d_%s", BackendProxyPrefix, port, hostname)
+}
+
+// ParseProxyName maps a proxy name generated by FrontendProxyName or
+// BackendProxyName back to the routing key it serves. Frontends serve every
+// route on their port, so only the port of their routing key is set.
+func ParseProxyName(name string) (ProxyType, RoutingKey, error) {
+	var (
+		proxyType ProxyType
+		rest      string
+	)
+	switch {
+	case strings.HasPrefix(name, FrontendProxyPrefix):
+		proxyType, rest = FrontendProxy, strings.TrimPrefix(name, FrontendProxyPrefix)
+	case strings.HasPrefix(name, BackendProxyPrefix):
+		proxyType, rest = BackendProxy, strings.TrimPrefix(name, BackendProxyPrefix)
+	default:
+		return 0, RoutingKey{}, fmt.Errorf("not a tcp router proxy name: %q", name)
+	}
+
+	// SNI hostnames may themselves contain underscores, so only split once
+	parts := strings.SplitN(rest, "_", 2)
+	port, err := strconv.ParseUint(parts[0], 10, 16)
+	if err != nil || port == 0 {
+		return 0, RoutingKey{}, fmt.Errorf("invalid port in proxy name: %q", name)
+	}
+
+	routingKey := RoutingKey{Port: uint16(port)}
+	if len(parts) == 2 {
+		if proxyType == FrontendProxy || parts[1] == "" {
+			return 0, RoutingKey{}, fmt.Errorf("invalid proxy name: %q", name)
+		}
+		hostname := parts[1]
+		if strings.HasPrefix(hostname, wildcardProxyNamePrefix) {
+			hostname = WildcardPrefix + strings.TrimPrefix(hostname, wildcardProxyNamePrefix)
+		}
+		routingKey.SniHostname = SniHostname(hostname)
+	}
+	return proxyType, routingKey, nil
+}
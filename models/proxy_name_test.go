@@ -0,0 +1,67 @@
+package models_test
+
+import (
+	"code.cloudfoundry.org/cf-tcp-router/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ProxyName", func() {
+	Describe("FrontendProxyName", func() {
+		It("names the frontend after its port", func() {
+			Expect(models.FrontendProxyName(9000)).To(Equal("frontend_9000"))
+		})
+	})
+
+	Describe("BackendProxyName", func() {
+		It("names the non-SNI backend after its port", func() {
+			Expect(models.BackendProxyName(9000, "")).To(Equal("backend_9000"))
+		})
+
+		It("names SNI backends after their port and hostname", func() {
+			Expect(models.BackendProxyName(9000, "sni.example.com")).To(Equal("backend_9000_sni.example.com"))
+		})
+
+		It("spells out the wildcard of wildcard SNI hostnames", func() {
+			Expect(models.BackendProxyName(9000, "*.db.example.com")).To(Equal("backend_9000_wildcard:db.example.com"))
+		})
+	})
+
+	Describe("ParseProxyName", func() {
+		DescribeTable("valid proxy names",
+			func(name string, expectedType models.ProxyType, expectedKey models.RoutingKey) {
+				proxyType, key, err := models.ParseProxyName(name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(proxyType).To(Equal(expectedType))
+				Expect(key).To(Equal(expectedKey))
+			},
+			Entry("frontend", "frontend_9000", models.FrontendProxy, models.RoutingKey{Port: 9000}),
+			Entry("non-SNI backend", "backend_9000", models.BackendProxy, models.RoutingKey{Port: 9000}),
+			Entry("SNI backend", "backend_9000_sni.example.com", models.BackendProxy, models.RoutingKey{Port: 9000, SniHostname: "sni.example.com"}),
+			Entry("SNI backend with underscores", "backend_9000_my_app.example.com", models.BackendProxy, models.RoutingKey{Port: 9000, SniHostname: "my_app.example.com"}),
+			Entry("wildcard SNI backend", "backend_9000_wildcard:db.example.com", models.BackendProxy, models.RoutingKey{Port: 9000, SniHostname: "*.db.example.com"}),
+		)
+
+		DescribeTable("invalid proxy names",
+			func(name string) {
+				_, _, err := models.ParseProxyName(name)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("unknown prefix", "listen_cfg_9000"),
+			Entry("stats proxy", "stats"),
+			Entry("non numeric port", "backend_BAD"),
+			Entry("zero port", "backend_0"),
+			Entry("port out of range", "frontend_70000"),
+			Entry("frontend with hostname", "frontend_9000_sni.example.com"),
+			Entry("empty hostname", "backend_9000_"),
+		)
+
+		It("round trips generated names", func() {
+			for _, key := range []models.RoutingKey{{Port: 80}, {Port: 443, SniHostname: "a_b.example.com"}, {Port: 443, SniHostname: "*.example.com"}} {
+				_, parsed, err := models.ParseProxyName(models.BackendProxyName(models.HAProxyInboundPort(key.Port), key.SniHostname))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(parsed).To(Equal(key))
+			}
+		})
+	})
+})
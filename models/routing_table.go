@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"code.cloudfoundry.org/lager/v3"
@@ -11,6 +12,20 @@ import (
 
 type SniHostname string
 
+// WildcardPrefix starts SNI hostnames, such as "*.db.example.com", that match
+// any hostname ending in the rest of the hostname.
+const WildcardPrefix = "*."
+
+func (h SniHostname) IsWildcard() bool {
+	return strings.HasPrefix(string(h), WildcardPrefix)
+}
+
+// Suffix returns the part of a wildcard hostname that matching hostnames end
+// with, e.g. ".db.example.com".
+func (h SniHostname) Suffix() string {
+	return strings.TrimPrefix(string(h), "*")
+}
+
 type RoutingKey struct {
 	Port        uint16
 	SniHostname SniHostname
@@ -73,13 +88,17 @@ func NewRoutingTableWithSession(logger lager.Logger, session string) RoutingTabl
 	}
 }
 
-func (e RoutingTableEntry) PruneBackends(defaultTTL int, logger lager.Logger) {
+// Returns the keys of the backends that were removed from the entry.
+func (e RoutingTableEntry) PruneBackends(defaultTTL int, logger lager.Logger) []BackendServerKey {
+	var pruned []BackendServerKey
 	for backendKey, details := range e.Backends {
 		if details.Expired(defaultTTL) {
 			logger.Debug("pruning-backend", lager.Data{"backend": backendKey, "details": details})
 			delete(e.Backends, backendKey)
+			pruned = append(pruned, backendKey)
 		}
 	}
+	return pruned
 }
 
 // Used to determine whether the details have changed such that the routing configuration needs to be updated.
@@ -107,14 +126,20 @@ func (d BackendServerDetails) Expired(defaultTTL int) bool {
 	return expiryTime.After(d.UpdatedTime)
 }
 
-func (table RoutingTable) PruneEntries(defaultTTL int) {
+// Returns the backends that were removed from the table, grouped by routing key.
+func (table RoutingTable) PruneEntries(defaultTTL int) map[RoutingKey][]BackendServerKey {
+	pruned := map[RoutingKey][]BackendServerKey{}
 	for routeKey, entry := range table.Entries {
-		entry.PruneBackends(defaultTTL, table.logger)
+		prunedBackends := entry.PruneBackends(defaultTTL, table.logger)
+		if len(prunedBackends) > 0 {
+			pruned[routeKey] = prunedBackends
+		}
 		if len(entry.Backends) == 0 {
 			table.logger.Debug("deleting-route-with-no-backends", lager.Data{"key": routeKey})
 			delete(table.Entries, routeKey)
 		}
 	}
+	return pruned
 }
 
 func (table RoutingTable) serverKeyDetailsFromInfo(info BackendServerInfo) (BackendServerKey, BackendServerDetails) {
@@ -192,10 +217,37 @@ func (table RoutingTable) Get(key RoutingKey) RoutingTableEntry {
 	return table.Entries[key]
 }
 
+// Returns a deep copy of the table entries. The copy shares the table's logger.
+func (table RoutingTable) Copy() RoutingTable {
+	entries := make(map[RoutingKey]RoutingTableEntry, len(table.Entries))
+	for key, entry := range table.Entries {
+		backends := make(map[BackendServerKey]BackendServerDetails, len(entry.Backends))
+		for backendKey, details := range entry.Backends {
+			backends[backendKey] = details
+		}
+		entries[key] = RoutingTableEntry{Backends: backends}
+	}
+	return RoutingTable{
+		Entries: entries,
+		logger:  table.logger,
+	}
+}
+
 func (table RoutingTable) Size() int {
 	return len(table.Entries)
 }
 
+// String names the route in metric names, which use dots to separate the
+// route from the metric. The SNI hostname is therefore spelled without dots,
+// with the wildcard of a wildcard hostname spelled "wildcard:" as in proxy
+// names, e.g. "9000_wildcard:db_example_com".
 func (k RoutingKey) String() string {
+	if k.SniHostname != "" {
+		hostname := string(k.SniHostname)
+		if k.SniHostname.IsWildcard() {
+			hostname = wildcardProxyNamePrefix + strings.TrimPrefix(hostname, WildcardPrefix)
+		}
+		return fmt.Sprintf("%d_%s", k.Port, strings.ReplaceAll(hostname, ".", "_"))
+	}
 	return fmt.Sprintf("%d", k.Port)
 }
@@ -641,6 +641,7 @@ var _ = Describe("RoutingTable", func() {
 			defaultTTL  int
 			routingKey1 models.RoutingKey
 			routingKey2 models.RoutingKey
+			pruned      map[models.RoutingKey][]models.BackendServerKey
 		)
 		BeforeEach(func() {
 			routingKey1 = models.RoutingKey{Port: 12}
@@ -671,7 +672,7 @@ var _ = Describe("RoutingTable", func() {
 		})
 
 		JustBeforeEach(func() {
-			routingTable.PruneEntries(defaultTTL)
+			pruned = routingTable.PruneEntries(defaultTTL)
 		})
 
 		Context("when it has expired entries", func() {
@@ -685,6 +686,12 @@ var _ = Describe("RoutingTable", func() {
 				Expect(routingTable.Get(routingKey2).Backends).To(HaveLen(1))
 			})
 
+			It("returns the pruned backends for each routing key", func() {
+				Expect(pruned).To(HaveLen(2))
+				Expect(pruned[routingKey1]).To(ConsistOf(models.BackendServerKey{Address: "some-ip-1", Port: 1234}))
+				Expect(pruned[routingKey2]).To(ConsistOf(models.BackendServerKey{Address: "some-ip-3", Port: 1234}))
+			})
+
 			Context("when all the backends expire for given routing key", func() {
 				BeforeEach(func() {
 					defaultTTL = 2
@@ -694,6 +701,13 @@ var _ = Describe("RoutingTable", func() {
 					Expect(routingTable.Entries).To(HaveLen(1))
 					Expect(routingTable.Get(routingKey2).Backends).To(HaveLen(1))
 				})
+
+				It("returns all the backends of the deleted routing key", func() {
+					Expect(pruned[routingKey1]).To(ConsistOf(
+						models.BackendServerKey{Address: "some-ip-1", Port: 1234},
+						models.BackendServerKey{Address: "some-ip-2", Port: 1235},
+					))
+				})
 			})
 		})
 
@@ -707,6 +721,35 @@ var _ = Describe("RoutingTable", func() {
 				Expect(routingTable.Get(routingKey1).Backends).To(HaveLen(2))
 				Expect(routingTable.Get(routingKey2).Backends).To(HaveLen(2))
 			})
+
+			It("returns no pruned backends", func() {
+				Expect(pruned).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Copy", func() {
+		var routingKey models.RoutingKey
+
+		BeforeEach(func() {
+			routingKey = models.RoutingKey{Port: 12}
+			routingTableEntry := models.NewRoutingTableEntry([]models.BackendServerInfo{
+				{Address: "some-ip-1", Port: 1234, ModificationTag: modificationTag},
+			})
+			Expect(routingTable.Set(routingKey, routingTableEntry)).To(BeTrue())
+		})
+
+		It("returns a table with the same entries", func() {
+			copied := routingTable.Copy()
+			Expect(copied.Entries).To(Equal(routingTable.Entries))
+		})
+
+		It("does not share backends with the original table", func() {
+			copied := routingTable.Copy()
+			routingTable.UpsertBackendServerKey(routingKey, models.BackendServerInfo{Address: "some-ip-2", Port: 1234, ModificationTag: modificationTag})
+			delete(routingTable.Entries, routingKey)
+			Expect(copied.Size()).To(Equal(1))
+			Expect(copied.Get(routingKey).Backends).To(HaveLen(1))
 		})
 	})
 
@@ -745,6 +788,7 @@ var _ = Describe("RoutingTable", func() {
 		var (
 			routingTableEntry models.RoutingTableEntry
 			defaultTTL        int
+			pruned            []models.BackendServerKey
 		)
 
 		BeforeEach(func() {
@@ -760,7 +804,7 @@ var _ = Describe("RoutingTable", func() {
 		})
 
 		JustBeforeEach(func() {
-			routingTableEntry.PruneBackends(defaultTTL, logger)
+			pruned = routingTableEntry.PruneBackends(defaultTTL, logger)
 		})
 
 		Context("when it has expired backends", func() {
@@ -770,6 +814,7 @@ var _ = Describe("RoutingTable", func() {
 
 			It("prunes expired backends", func() {
 				Expect(routingTableEntry.Backends).To(HaveLen(1))
+				Expect(pruned).To(ConsistOf(models.BackendServerKey{Address: "some-ip-1", Port: 1234}))
 			})
 		})
 
@@ -780,6 +825,7 @@ var _ = Describe("RoutingTable", func() {
 
 			It("prunes expired backends", func() {
 				Expect(routingTableEntry.Backends).To(HaveLen(2))
+				Expect(pruned).To(BeEmpty())
 			})
 		})
 	})
@@ -0,0 +1,123 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/cf-tcp-router/metrics_reporter"
+	"code.cloudfoundry.org/clock"
+)
+
+// Policy describes how calls to routing api and uaa are retried. The delay
+// before the n-th retry is InitialDelay * Multiplier^(n-1), moved up or down
+// by a random fraction of at most Jitter so that routers do not retry in
+// lockstep, and capped at MaxDelay.
+type Policy struct {
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+	Jitter       float64
+	// MaxAttempts limits how often a single call is attempted. 0 means the
+	// call is retried until it succeeds.
+	MaxAttempts int
+}
+
+func (p Policy) Validate() error {
+	if p.InitialDelay <= 0 {
+		return errors.New("initial retry delay must be greater than 0")
+	}
+	if p.MaxDelay < p.InitialDelay {
+		return errors.New("max retry delay cannot be less than the initial retry delay")
+	}
+	if p.Multiplier < 1 {
+		return errors.New("retry multiplier cannot be less than 1")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return errors.New("retry jitter must be between 0 and 1")
+	}
+	if p.MaxAttempts < 0 {
+		return errors.New("max retry attempts cannot be negative")
+	}
+	return nil
+}
+
+// Backoff tracks the consecutive failures of one kind of call, which may span
+// several retried calls, and reports them through a metric.
+type Backoff struct {
+	policy              Policy
+	failures            metrics_reporter.Value
+	lock                *sync.Mutex
+	consecutiveFailures int
+}
+
+func NewBackoff(policy Policy, failures metrics_reporter.Value) *Backoff {
+	return &Backoff{
+		policy:   policy,
+		failures: failures,
+		lock:     new(sync.Mutex),
+	}
+}
+
+// Failed records a failure and returns how long to wait before trying again.
+func (b *Backoff) Failed() time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.consecutiveFailures++
+	b.failures.Send(uint64(b.consecutiveFailures))
+	return b.policy.delay(b.consecutiveFailures)
+}
+
+// Succeeded resets the consecutive failures.
+func (b *Backoff) Succeeded() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.consecutiveFailures > 0 {
+		b.consecutiveFailures = 0
+		b.failures.Send(0)
+	}
+}
+
+func (b *Backoff) ConsecutiveFailures() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.consecutiveFailures
+}
+
+// Do calls fn until it succeeds or the policy's MaxAttempts is reached,
+// waiting between attempts, and returns the last error. When ctx is done while
+// waiting, Do stops retrying and returns the error of ctx.
+func (b *Backoff) Do(ctx context.Context, clock clock.Clock, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			b.Succeeded()
+			return nil
+		}
+
+		delay := b.Failed()
+		if b.policy.MaxAttempts > 0 && attempt >= b.policy.MaxAttempts {
+			return err
+		}
+		timer := clock.NewTimer(delay)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+func (p Policy) delay(failures int) time.Duration {
+	delay := float64(p.InitialDelay) * math.Pow(p.Multiplier, float64(failures-1))
+	// #nosec G404 - jitter does not need a cryptographically secure source
+	delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	if delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,168 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/cf-tcp-router/retry"
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/cloudfoundry/dropsonde/metric_sender/fake"
+	"github.com/cloudfoundry/dropsonde/metrics"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Retry", func() {
+	var (
+		policy retry.Policy
+		sender *fake.FakeMetricSender
+	)
+
+	BeforeEach(func() {
+		policy = retry.Policy{
+			InitialDelay: time.Second,
+			MaxDelay:     5 * time.Second,
+			Multiplier:   2,
+		}
+		sender = fake.NewFakeMetricSender()
+		metrics.Initialize(sender, nil)
+	})
+
+	Describe("Policy.Validate", func() {
+		It("accepts a valid policy", func() {
+			Expect(policy.Validate()).To(Succeed())
+		})
+
+		DescribeTable("invalid policies",
+			func(modify func(*retry.Policy), message string) {
+				modify(&policy)
+				Expect(policy.Validate()).To(MatchError(message))
+			},
+			Entry("no initial delay", func(p *retry.Policy) { p.InitialDelay = 0 }, "initial retry delay must be greater than 0"),
+			Entry("max delay below initial delay", func(p *retry.Policy) { p.MaxDelay = time.Millisecond }, "max retry delay cannot be less than the initial retry delay"),
+			Entry("shrinking delays", func(p *retry.Policy) { p.Multiplier = 0.5 }, "retry multiplier cannot be less than 1"),
+			Entry("jitter above 1", func(p *retry.Policy) { p.Jitter = 1.5 }, "retry jitter must be between 0 and 1"),
+			Entry("negative max attempts", func(p *retry.Policy) { p.MaxAttempts = -1 }, "max retry attempts cannot be negative"),
+		)
+	})
+
+	Describe("Backoff", func() {
+		It("grows the delay exponentially up to the max delay", func() {
+			backoff := retry.NewBackoff(policy, "ConsecutiveFailures")
+			var delays []time.Duration
+			for i := 0; i < 5; i++ {
+				delays = append(delays, backoff.Failed())
+			}
+			Expect(delays).To(Equal([]time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}))
+		})
+
+		It("moves the delay by at most the jitter", func() {
+			policy.Jitter = 0.5
+			for i := 0; i < 100; i++ {
+				delay := retry.NewBackoff(policy, "ConsecutiveFailures").Failed()
+				Expect(delay).To(BeNumerically(">=", 500*time.Millisecond))
+				Expect(delay).To(BeNumerically("<=", 1500*time.Millisecond))
+			}
+		})
+
+		It("does not exceed the max delay with jitter", func() {
+			policy.Jitter = 0.5
+			for i := 0; i < 100; i++ {
+				backoff := retry.NewBackoff(policy, "ConsecutiveFailures")
+				backoff.Failed()
+				backoff.Failed()
+				delay := backoff.Failed()
+				Expect(delay).To(BeNumerically(">=", 2*time.Second))
+				Expect(delay).To(BeNumerically("<=", 5*time.Second))
+			}
+		})
+
+		It("reports consecutive failures until a success", func() {
+			backoff := retry.NewBackoff(policy, "ConsecutiveFailures")
+			backoff.Failed()
+			backoff.Failed()
+			Expect(backoff.ConsecutiveFailures()).To(Equal(2))
+			Expect(sender.GetValue("ConsecutiveFailures").Value).To(Equal(float64(2)))
+
+			backoff.Succeeded()
+			Expect(backoff.ConsecutiveFailures()).To(Equal(0))
+			Expect(sender.GetValue("ConsecutiveFailures").Value).To(Equal(float64(0)))
+			Expect(backoff.Failed()).To(Equal(time.Second))
+		})
+	})
+
+	Describe("Do", func() {
+		var (
+			fakeClock *fakeclock.FakeClock
+			backoff   *retry.Backoff
+			calls     int
+		)
+
+		BeforeEach(func() {
+			fakeClock = fakeclock.NewFakeClock(time.Now())
+			calls = 0
+		})
+
+		JustBeforeEach(func() {
+			backoff = retry.NewBackoff(policy, "ConsecutiveFailures")
+		})
+
+		It("waits between attempts until the call succeeds", func() {
+			done := make(chan error)
+			go func() {
+				done <- backoff.Do(context.Background(), fakeClock, func() error {
+					calls++
+					if calls < 3 {
+						return errors.New("boom")
+					}
+					return nil
+				})
+			}()
+
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			fakeClock.WaitForWatcherAndIncrement(2 * time.Second)
+			Eventually(done).Should(Receive(BeNil()))
+			Expect(calls).To(Equal(3))
+			Expect(backoff.ConsecutiveFailures()).To(Equal(0))
+		})
+
+		Context("when max attempts is set", func() {
+			BeforeEach(func() {
+				policy.MaxAttempts = 2
+			})
+
+			It("returns the last error once the attempts are used up", func() {
+				done := make(chan error)
+				go func() {
+					done <- backoff.Do(context.Background(), fakeClock, func() error {
+						calls++
+						return errors.New("boom")
+					})
+				}()
+
+				fakeClock.WaitForWatcherAndIncrement(time.Second)
+				Eventually(done).Should(Receive(MatchError("boom")))
+				Expect(calls).To(Equal(2))
+				Expect(backoff.ConsecutiveFailures()).To(Equal(2))
+			})
+		})
+
+		It("stops retrying when the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() {
+				done <- backoff.Do(ctx, fakeClock, func() error {
+					calls++
+					return errors.New("boom")
+				})
+			}()
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			cancel()
+			Eventually(done).Should(Receive(MatchError(context.Canceled)))
+			Expect(calls).To(Equal(1))
+		})
+	})
+})
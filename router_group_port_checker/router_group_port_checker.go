@@ -6,21 +6,30 @@ import (
 	"fmt"
 	"strings"
 
+	"code.cloudfoundry.org/cf-tcp-router/metrics_reporter"
+	"code.cloudfoundry.org/cf-tcp-router/retry"
+	"code.cloudfoundry.org/clock"
 	routing_api "code.cloudfoundry.org/routing-api"
 	"code.cloudfoundry.org/routing-api/models"
 	"code.cloudfoundry.org/routing-api/uaaclient"
 	"golang.org/x/oauth2"
 )
 
+var consecutiveRouterGroupFetchFailures = metrics_reporter.Value("ConsecutiveRouterGroupFetchFailures")
+
 type PortChecker struct {
 	routingAPIClient routing_api.Client
 	uaaTokenFetcher  uaaclient.TokenFetcher
+	clock            clock.Clock
+	backoff          *retry.Backoff
 }
 
-func NewPortChecker(routingAPIClient routing_api.Client, uaaTokenFetcher uaaclient.TokenFetcher) PortChecker {
+func NewPortChecker(routingAPIClient routing_api.Client, uaaTokenFetcher uaaclient.TokenFetcher, clock clock.Clock, retryPolicy retry.Policy) PortChecker {
 	return PortChecker{
 		routingAPIClient: routingAPIClient,
 		uaaTokenFetcher:  uaaTokenFetcher,
+		clock:            clock,
+		backoff:          retry.NewBackoff(retryPolicy, consecutiveRouterGroupFetchFailures),
 	}
 }
 
@@ -38,32 +47,52 @@ func (pc *PortChecker) Check(systemComponentPorts []uint16) (bool, error) {
 	return shouldExit, errors.New(strings.Join(portErrors, "\n"))
 }
 
-func (pc *PortChecker) getRouterGroups() ([]models.RouterGroup, error) {
-	var err error
-	numRetries := 3
+// RouterGroupGuids looks up the guids of the router groups with the given
+// names. It returns an error if any of them does not exist.
+func (pc *PortChecker) RouterGroupGuids(names []string) ([]string, error) {
+	routerGroups, err := pc.getRouterGroups()
+	if err != nil {
+		return nil, err
+	}
 
-	for i := 0; i < numRetries; i++ {
-		var token *oauth2.Token
-		token, err = pc.uaaTokenFetcher.FetchToken(context.Background(), false)
-		if err != nil {
-			continue
+	guidsByName := map[string]string{}
+	for _, group := range routerGroups {
+		guidsByName[group.Name] = group.Guid
+	}
+
+	guids := make([]string, 0, len(names))
+	for _, name := range names {
+		guid, ok := guidsByName[name]
+		if !ok {
+			return nil, fmt.Errorf("router group '%s' does not exist", name)
 		}
-		pc.routingAPIClient.SetToken(token.AccessToken)
-		break
+		guids = append(guids, guid)
 	}
+	return guids, nil
+}
 
+func (pc *PortChecker) getRouterGroups() ([]models.RouterGroup, error) {
+	var token *oauth2.Token
+	err := pc.backoff.Do(context.Background(), pc.clock, func() error {
+		var err error
+		token, err = pc.uaaTokenFetcher.FetchToken(context.Background(), false)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error-fetching-uaa-token: \"%s\"", err.Error())
 	}
+	pc.routingAPIClient.SetToken(token.AccessToken)
 
-	for i := 0; i < numRetries; i++ {
-		var routerGroups []models.RouterGroup
+	var routerGroups []models.RouterGroup
+	err = pc.backoff.Do(context.Background(), pc.clock, func() error {
+		var err error
 		routerGroups, err = pc.routingAPIClient.RouterGroups()
-		if err == nil {
-			return routerGroups, nil
-		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error-fetching-routing-groups: \"%s\"", err.Error())
 	}
-	return nil, fmt.Errorf("error-fetching-routing-groups: \"%s\"", err.Error())
+	return routerGroups, nil
 }
 
 func validateRouterGroups(routerGroups []models.RouterGroup, systemComponentPorts []uint16) (bool, []string) {
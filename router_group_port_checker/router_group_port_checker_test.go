@@ -5,11 +5,15 @@ import (
 	"time"
 
 	"code.cloudfoundry.org/routing-api/fake_routing_api"
+	"github.com/cloudfoundry/dropsonde/metric_sender/fake"
+	"github.com/cloudfoundry/dropsonde/metrics"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"golang.org/x/oauth2"
 
+	"code.cloudfoundry.org/cf-tcp-router/retry"
 	"code.cloudfoundry.org/cf-tcp-router/router_group_port_checker"
+	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/routing-api/models"
 	test_uaa_client "code.cloudfoundry.org/routing-api/uaaclient/fakes"
 )
@@ -20,8 +24,15 @@ var _ = Describe("RouterGroupPortChecker", func() {
 		fakeTokenFetcher           *test_uaa_client.FakeTokenFetcher
 		token                      *oauth2.Token
 		routerGroup1, routerGroup2 models.RouterGroup
+		retryPolicy                retry.Policy
 	)
 	BeforeEach(func() {
+		retryPolicy = retry.Policy{
+			InitialDelay: time.Millisecond,
+			MaxDelay:     time.Millisecond,
+			Multiplier:   1,
+			MaxAttempts:  3,
+		}
 
 		fakeRoutingApiClient = new(fake_routing_api.FakeClient)
 		fakeTokenFetcher = &test_uaa_client.FakeTokenFetcher{}
@@ -44,7 +55,7 @@ var _ = Describe("RouterGroupPortChecker", func() {
 	It("doesn't return an error when there is no overlaps and should not exit", func() {
 		fakeTokenFetcher.FetchTokenReturns(token, nil)
 		fakeRoutingApiClient.RouterGroupsReturns([]models.RouterGroup{routerGroup1}, nil)
-		checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher)
+		checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher, clock.NewClock(), retryPolicy)
 		shouldExit, err := checker.Check([]uint16{2048})
 
 		Expect(fakeRoutingApiClient.SetTokenArgsForCall(0)).To(Equal(token.AccessToken))
@@ -55,7 +66,7 @@ var _ = Describe("RouterGroupPortChecker", func() {
 	It("Returns an error when there is an overlap and should exit", func() {
 		fakeTokenFetcher.FetchTokenReturns(token, nil)
 		fakeRoutingApiClient.RouterGroupsReturns([]models.RouterGroup{routerGroup1}, nil)
-		checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher)
+		checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher, clock.NewClock(), retryPolicy)
 		shouldExit, err := checker.Check([]uint16{1026})
 
 		Expect(fakeRoutingApiClient.SetTokenArgsForCall(0)).To(Equal(token.AccessToken))
@@ -68,7 +79,7 @@ var _ = Describe("RouterGroupPortChecker", func() {
 	It("Returns multiple errors when there is multiple overlaps and should exit", func() {
 		fakeTokenFetcher.FetchTokenReturns(token, nil)
 		fakeRoutingApiClient.RouterGroupsReturns([]models.RouterGroup{routerGroup1, routerGroup2}, nil)
-		checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher)
+		checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher, clock.NewClock(), retryPolicy)
 		shouldExit, err := checker.Check([]uint16{1026, 1027, 2001, 2002})
 
 		Expect(fakeRoutingApiClient.SetTokenArgsForCall(0)).To(Equal(token.AccessToken))
@@ -90,14 +101,14 @@ var _ = Describe("RouterGroupPortChecker", func() {
 			})
 
 			It("doesn't error when there is no overlap and should not exit", func() {
-				checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher)
+				checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher, clock.NewClock(), retryPolicy)
 				shouldExit, err := checker.Check([]uint16{2048})
 				Expect(err).To(BeNil())
 				Expect(shouldExit).To(BeFalse())
 			})
 
 			It("returns an error when there is an overlap and should exit", func() {
-				checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher)
+				checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher, clock.NewClock(), retryPolicy)
 				shouldExit, err := checker.Check([]uint16{1026})
 				msg := "The reserved ports for router group 'router-group-1' contains the following reserved system component port(s): '1026'. Please update your router group accordingly."
 				Expect(err).To(MatchError(msg))
@@ -112,11 +123,22 @@ var _ = Describe("RouterGroupPortChecker", func() {
 			})
 
 			It("returns an error and should not exit", func() {
-				checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher)
+				checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher, clock.NewClock(), retryPolicy)
 				shouldExit, err := checker.Check([]uint16{})
 				Expect(err).To(MatchError("error-fetching-routing-groups: \"oh no!\""))
 				Expect(shouldExit).To(BeFalse())
 			})
+
+			It("gives up after the max attempts and reports the consecutive failures", func() {
+				sender := fake.NewFakeMetricSender()
+				metrics.Initialize(sender, nil)
+
+				checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher, clock.NewClock(), retryPolicy)
+				_, err := checker.Check([]uint16{})
+				Expect(err).To(HaveOccurred())
+				Expect(fakeRoutingApiClient.RouterGroupsCallCount()).To(Equal(3))
+				Expect(sender.GetValue("ConsecutiveRouterGroupFetchFailures").Value).To(Equal(float64(3)))
+			})
 		})
 	})
 
@@ -130,14 +152,14 @@ var _ = Describe("RouterGroupPortChecker", func() {
 			})
 
 			It("doesn't error when there is no overlap and should not exit", func() {
-				checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher)
+				checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher, clock.NewClock(), retryPolicy)
 				shouldExit, err := checker.Check([]uint16{2048})
 				Expect(err).To(BeNil())
 				Expect(shouldExit).To(BeFalse())
 			})
 
 			It("returns an error when there is an overlap and should exit", func() {
-				checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher)
+				checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher, clock.NewClock(), retryPolicy)
 				shouldExit, err := checker.Check([]uint16{1026})
 				msg := "The reserved ports for router group 'router-group-1' contains the following reserved system component port(s): '1026'. Please update your router group accordingly."
 				Expect(err).To(MatchError(msg))
@@ -149,11 +171,33 @@ var _ = Describe("RouterGroupPortChecker", func() {
 				fakeTokenFetcher.FetchTokenReturns(nil, errors.New("oh no!"))
 			})
 			It("returns an error and should not exit", func() {
-				checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher)
+				checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher, clock.NewClock(), retryPolicy)
 				shouldExit, err := checker.Check([]uint16{})
 				Expect(err).To(MatchError("error-fetching-uaa-token: \"oh no!\""))
 				Expect(shouldExit).To(BeFalse())
 			})
 		})
 	})
+
+	Describe("RouterGroupGuids", func() {
+		BeforeEach(func() {
+			routerGroup1.Guid = "guid-1"
+			routerGroup2.Guid = "guid-2"
+			fakeTokenFetcher.FetchTokenReturns(token, nil)
+			fakeRoutingApiClient.RouterGroupsReturns([]models.RouterGroup{routerGroup1, routerGroup2}, nil)
+		})
+
+		It("returns the guids of the named router groups", func() {
+			checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher, clock.NewClock(), retryPolicy)
+			guids, err := checker.RouterGroupGuids([]string{"router-group-2", "router-group-1"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(guids).To(Equal([]string{"guid-2", "guid-1"}))
+		})
+
+		It("returns an error when a router group does not exist", func() {
+			checker := router_group_port_checker.NewPortChecker(fakeRoutingApiClient, fakeTokenFetcher, clock.NewClock(), retryPolicy)
+			_, err := checker.RouterGroupGuids([]string{"router-group-3"})
+			Expect(err).To(MatchError("router group 'router-group-3' does not exist"))
+		})
+	})
 })
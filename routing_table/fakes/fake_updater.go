@@ -3,7 +3,9 @@ package fakes
 
 import (
 	"sync"
+	"time"
 
+	"code.cloudfoundry.org/cf-tcp-router/models"
 	"code.cloudfoundry.org/cf-tcp-router/routing_table"
 	routing_api "code.cloudfoundry.org/routing-api"
 )
@@ -30,14 +32,79 @@ type FakeUpdater struct {
 	handleEventReturnsOnCall map[int]struct {
 		result1 error
 	}
+	IsDrainingStub        func() bool
+	isDrainingMutex       sync.RWMutex
+	isDrainingArgsForCall []struct {
+	}
+	isDrainingReturns struct {
+		result1 bool
+	}
+	isDrainingReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	LastSyncTimeStub        func() time.Time
+	lastSyncTimeMutex       sync.RWMutex
+	lastSyncTimeArgsForCall []struct {
+	}
+	lastSyncTimeReturns struct {
+		result1 time.Time
+	}
+	lastSyncTimeReturnsOnCall map[int]struct {
+		result1 time.Time
+	}
 	PruneStaleRoutesStub        func()
 	pruneStaleRoutesMutex       sync.RWMutex
 	pruneStaleRoutesArgsForCall []struct {
 	}
+	ReadyStub        func() bool
+	readyMutex       sync.RWMutex
+	readyArgsForCall []struct {
+	}
+	readyReturns struct {
+		result1 bool
+	}
+	readyReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	ReconfigureStub        func() error
+	reconfigureMutex       sync.RWMutex
+	reconfigureArgsForCall []struct {
+	}
+	reconfigureReturns struct {
+		result1 error
+	}
+	reconfigureReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RoutingTableStub        func() models.RoutingTable
+	routingTableMutex       sync.RWMutex
+	routingTableArgsForCall []struct {
+	}
+	routingTableReturns struct {
+		result1 models.RoutingTable
+	}
+	routingTableReturnsOnCall map[int]struct {
+		result1 models.RoutingTable
+	}
+	SetDrainWaitDurationStub        func(time.Duration)
+	setDrainWaitDurationMutex       sync.RWMutex
+	setDrainWaitDurationArgsForCall []struct {
+		arg1 time.Duration
+	}
 	SyncStub        func()
 	syncMutex       sync.RWMutex
 	syncArgsForCall []struct {
 	}
+	SyncedStub        func() bool
+	syncedMutex       sync.RWMutex
+	syncedArgsForCall []struct {
+	}
+	syncedReturns struct {
+		result1 bool
+	}
+	syncedReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	SyncingStub        func() bool
 	syncingMutex       sync.RWMutex
 	syncingArgsForCall []struct {
@@ -166,6 +233,112 @@ func (fake *FakeUpdater) HandleEventReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeUpdater) IsDraining() bool {
+	fake.isDrainingMutex.Lock()
+	ret, specificReturn := fake.isDrainingReturnsOnCall[len(fake.isDrainingArgsForCall)]
+	fake.isDrainingArgsForCall = append(fake.isDrainingArgsForCall, struct {
+	}{})
+	stub := fake.IsDrainingStub
+	fakeReturns := fake.isDrainingReturns
+	fake.recordInvocation("IsDraining", []interface{}{})
+	fake.isDrainingMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeUpdater) IsDrainingCallCount() int {
+	fake.isDrainingMutex.RLock()
+	defer fake.isDrainingMutex.RUnlock()
+	return len(fake.isDrainingArgsForCall)
+}
+
+func (fake *FakeUpdater) IsDrainingCalls(stub func() bool) {
+	fake.isDrainingMutex.Lock()
+	defer fake.isDrainingMutex.Unlock()
+	fake.IsDrainingStub = stub
+}
+
+func (fake *FakeUpdater) IsDrainingReturns(result1 bool) {
+	fake.isDrainingMutex.Lock()
+	defer fake.isDrainingMutex.Unlock()
+	fake.IsDrainingStub = nil
+	fake.isDrainingReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeUpdater) IsDrainingReturnsOnCall(i int, result1 bool) {
+	fake.isDrainingMutex.Lock()
+	defer fake.isDrainingMutex.Unlock()
+	fake.IsDrainingStub = nil
+	if fake.isDrainingReturnsOnCall == nil {
+		fake.isDrainingReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.isDrainingReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeUpdater) LastSyncTime() time.Time {
+	fake.lastSyncTimeMutex.Lock()
+	ret, specificReturn := fake.lastSyncTimeReturnsOnCall[len(fake.lastSyncTimeArgsForCall)]
+	fake.lastSyncTimeArgsForCall = append(fake.lastSyncTimeArgsForCall, struct {
+	}{})
+	stub := fake.LastSyncTimeStub
+	fakeReturns := fake.lastSyncTimeReturns
+	fake.recordInvocation("LastSyncTime", []interface{}{})
+	fake.lastSyncTimeMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeUpdater) LastSyncTimeCallCount() int {
+	fake.lastSyncTimeMutex.RLock()
+	defer fake.lastSyncTimeMutex.RUnlock()
+	return len(fake.lastSyncTimeArgsForCall)
+}
+
+func (fake *FakeUpdater) LastSyncTimeCalls(stub func() time.Time) {
+	fake.lastSyncTimeMutex.Lock()
+	defer fake.lastSyncTimeMutex.Unlock()
+	fake.LastSyncTimeStub = stub
+}
+
+func (fake *FakeUpdater) LastSyncTimeReturns(result1 time.Time) {
+	fake.lastSyncTimeMutex.Lock()
+	defer fake.lastSyncTimeMutex.Unlock()
+	fake.LastSyncTimeStub = nil
+	fake.lastSyncTimeReturns = struct {
+		result1 time.Time
+	}{result1}
+}
+
+func (fake *FakeUpdater) LastSyncTimeReturnsOnCall(i int, result1 time.Time) {
+	fake.lastSyncTimeMutex.Lock()
+	defer fake.lastSyncTimeMutex.Unlock()
+	fake.LastSyncTimeStub = nil
+	if fake.lastSyncTimeReturnsOnCall == nil {
+		fake.lastSyncTimeReturnsOnCall = make(map[int]struct {
+			result1 time.Time
+		})
+	}
+	fake.lastSyncTimeReturnsOnCall[i] = struct {
+		result1 time.Time
+	}{result1}
+}
+
 func (fake *FakeUpdater) PruneStaleRoutes() {
 	fake.pruneStaleRoutesMutex.Lock()
 	fake.pruneStaleRoutesArgsForCall = append(fake.pruneStaleRoutesArgsForCall, struct {
@@ -190,6 +363,197 @@ func (fake *FakeUpdater) PruneStaleRoutesCalls(stub func()) {
 	fake.PruneStaleRoutesStub = stub
 }
 
+func (fake *FakeUpdater) Ready() bool {
+	fake.readyMutex.Lock()
+	ret, specificReturn := fake.readyReturnsOnCall[len(fake.readyArgsForCall)]
+	fake.readyArgsForCall = append(fake.readyArgsForCall, struct {
+	}{})
+	stub := fake.ReadyStub
+	fakeReturns := fake.readyReturns
+	fake.recordInvocation("Ready", []interface{}{})
+	fake.readyMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeUpdater) ReadyCallCount() int {
+	fake.readyMutex.RLock()
+	defer fake.readyMutex.RUnlock()
+	return len(fake.readyArgsForCall)
+}
+
+func (fake *FakeUpdater) ReadyCalls(stub func() bool) {
+	fake.readyMutex.Lock()
+	defer fake.readyMutex.Unlock()
+	fake.ReadyStub = stub
+}
+
+func (fake *FakeUpdater) ReadyReturns(result1 bool) {
+	fake.readyMutex.Lock()
+	defer fake.readyMutex.Unlock()
+	fake.ReadyStub = nil
+	fake.readyReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeUpdater) ReadyReturnsOnCall(i int, result1 bool) {
+	fake.readyMutex.Lock()
+	defer fake.readyMutex.Unlock()
+	fake.ReadyStub = nil
+	if fake.readyReturnsOnCall == nil {
+		fake.readyReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.readyReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeUpdater) Reconfigure() error {
+	fake.reconfigureMutex.Lock()
+	ret, specificReturn := fake.reconfigureReturnsOnCall[len(fake.reconfigureArgsForCall)]
+	fake.reconfigureArgsForCall = append(fake.reconfigureArgsForCall, struct {
+	}{})
+	stub := fake.ReconfigureStub
+	fakeReturns := fake.reconfigureReturns
+	fake.recordInvocation("Reconfigure", []interface{}{})
+	fake.reconfigureMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeUpdater) ReconfigureCallCount() int {
+	fake.reconfigureMutex.RLock()
+	defer fake.reconfigureMutex.RUnlock()
+	return len(fake.reconfigureArgsForCall)
+}
+
+func (fake *FakeUpdater) ReconfigureCalls(stub func() error) {
+	fake.reconfigureMutex.Lock()
+	defer fake.reconfigureMutex.Unlock()
+	fake.ReconfigureStub = stub
+}
+
+func (fake *FakeUpdater) ReconfigureReturns(result1 error) {
+	fake.reconfigureMutex.Lock()
+	defer fake.reconfigureMutex.Unlock()
+	fake.ReconfigureStub = nil
+	fake.reconfigureReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeUpdater) ReconfigureReturnsOnCall(i int, result1 error) {
+	fake.reconfigureMutex.Lock()
+	defer fake.reconfigureMutex.Unlock()
+	fake.ReconfigureStub = nil
+	if fake.reconfigureReturnsOnCall == nil {
+		fake.reconfigureReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.reconfigureReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeUpdater) RoutingTable() models.RoutingTable {
+	fake.routingTableMutex.Lock()
+	ret, specificReturn := fake.routingTableReturnsOnCall[len(fake.routingTableArgsForCall)]
+	fake.routingTableArgsForCall = append(fake.routingTableArgsForCall, struct {
+	}{})
+	stub := fake.RoutingTableStub
+	fakeReturns := fake.routingTableReturns
+	fake.recordInvocation("RoutingTable", []interface{}{})
+	fake.routingTableMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeUpdater) RoutingTableCallCount() int {
+	fake.routingTableMutex.RLock()
+	defer fake.routingTableMutex.RUnlock()
+	return len(fake.routingTableArgsForCall)
+}
+
+func (fake *FakeUpdater) RoutingTableCalls(stub func() models.RoutingTable) {
+	fake.routingTableMutex.Lock()
+	defer fake.routingTableMutex.Unlock()
+	fake.RoutingTableStub = stub
+}
+
+func (fake *FakeUpdater) RoutingTableReturns(result1 models.RoutingTable) {
+	fake.routingTableMutex.Lock()
+	defer fake.routingTableMutex.Unlock()
+	fake.RoutingTableStub = nil
+	fake.routingTableReturns = struct {
+		result1 models.RoutingTable
+	}{result1}
+}
+
+func (fake *FakeUpdater) RoutingTableReturnsOnCall(i int, result1 models.RoutingTable) {
+	fake.routingTableMutex.Lock()
+	defer fake.routingTableMutex.Unlock()
+	fake.RoutingTableStub = nil
+	if fake.routingTableReturnsOnCall == nil {
+		fake.routingTableReturnsOnCall = make(map[int]struct {
+			result1 models.RoutingTable
+		})
+	}
+	fake.routingTableReturnsOnCall[i] = struct {
+		result1 models.RoutingTable
+	}{result1}
+}
+
+func (fake *FakeUpdater) SetDrainWaitDuration(arg1 time.Duration) {
+	fake.setDrainWaitDurationMutex.Lock()
+	fake.setDrainWaitDurationArgsForCall = append(fake.setDrainWaitDurationArgsForCall, struct {
+		arg1 time.Duration
+	}{arg1})
+	stub := fake.SetDrainWaitDurationStub
+	fake.recordInvocation("SetDrainWaitDuration", []interface{}{arg1})
+	fake.setDrainWaitDurationMutex.Unlock()
+	if stub != nil {
+		fake.SetDrainWaitDurationStub(arg1)
+	}
+}
+
+func (fake *FakeUpdater) SetDrainWaitDurationCallCount() int {
+	fake.setDrainWaitDurationMutex.RLock()
+	defer fake.setDrainWaitDurationMutex.RUnlock()
+	return len(fake.setDrainWaitDurationArgsForCall)
+}
+
+func (fake *FakeUpdater) SetDrainWaitDurationCalls(stub func(time.Duration)) {
+	fake.setDrainWaitDurationMutex.Lock()
+	defer fake.setDrainWaitDurationMutex.Unlock()
+	fake.SetDrainWaitDurationStub = stub
+}
+
+func (fake *FakeUpdater) SetDrainWaitDurationArgsForCall(i int) time.Duration {
+	fake.setDrainWaitDurationMutex.RLock()
+	defer fake.setDrainWaitDurationMutex.RUnlock()
+	argsForCall := fake.setDrainWaitDurationArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeUpdater) Sync() {
 	fake.syncMutex.Lock()
 	fake.syncArgsForCall = append(fake.syncArgsForCall, struct {
@@ -214,6 +578,59 @@ func (fake *FakeUpdater) SyncCalls(stub func()) {
 	fake.SyncStub = stub
 }
 
+func (fake *FakeUpdater) Synced() bool {
+	fake.syncedMutex.Lock()
+	ret, specificReturn := fake.syncedReturnsOnCall[len(fake.syncedArgsForCall)]
+	fake.syncedArgsForCall = append(fake.syncedArgsForCall, struct {
+	}{})
+	stub := fake.SyncedStub
+	fakeReturns := fake.syncedReturns
+	fake.recordInvocation("Synced", []interface{}{})
+	fake.syncedMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeUpdater) SyncedCallCount() int {
+	fake.syncedMutex.RLock()
+	defer fake.syncedMutex.RUnlock()
+	return len(fake.syncedArgsForCall)
+}
+
+func (fake *FakeUpdater) SyncedCalls(stub func() bool) {
+	fake.syncedMutex.Lock()
+	defer fake.syncedMutex.Unlock()
+	fake.SyncedStub = stub
+}
+
+func (fake *FakeUpdater) SyncedReturns(result1 bool) {
+	fake.syncedMutex.Lock()
+	defer fake.syncedMutex.Unlock()
+	fake.SyncedStub = nil
+	fake.syncedReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeUpdater) SyncedReturnsOnCall(i int, result1 bool) {
+	fake.syncedMutex.Lock()
+	defer fake.syncedMutex.Unlock()
+	fake.SyncedStub = nil
+	if fake.syncedReturnsOnCall == nil {
+		fake.syncedReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.syncedReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *FakeUpdater) Syncing() bool {
 	fake.syncingMutex.Lock()
 	ret, specificReturn := fake.syncingReturnsOnCall[len(fake.syncingArgsForCall)]
@@ -274,10 +691,24 @@ func (fake *FakeUpdater) Invocations() map[string][][]interface{} {
 	defer fake.drainMutex.RUnlock()
 	fake.handleEventMutex.RLock()
 	defer fake.handleEventMutex.RUnlock()
+	fake.isDrainingMutex.RLock()
+	defer fake.isDrainingMutex.RUnlock()
+	fake.lastSyncTimeMutex.RLock()
+	defer fake.lastSyncTimeMutex.RUnlock()
 	fake.pruneStaleRoutesMutex.RLock()
 	defer fake.pruneStaleRoutesMutex.RUnlock()
+	fake.readyMutex.RLock()
+	defer fake.readyMutex.RUnlock()
+	fake.reconfigureMutex.RLock()
+	defer fake.reconfigureMutex.RUnlock()
+	fake.routingTableMutex.RLock()
+	defer fake.routingTableMutex.RUnlock()
+	fake.setDrainWaitDurationMutex.RLock()
+	defer fake.setDrainWaitDurationMutex.RUnlock()
 	fake.syncMutex.RLock()
 	defer fake.syncMutex.RUnlock()
+	fake.syncedMutex.RLock()
+	defer fake.syncedMutex.RUnlock()
 	fake.syncingMutex.RLock()
 	defer fake.syncingMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
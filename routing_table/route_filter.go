@@ -0,0 +1,33 @@
+package routing_table
+
+import (
+	apimodels "code.cloudfoundry.org/routing-api/models"
+)
+
+// RouteFilter selects the TCP routes served by this router. An empty list of
+// router groups or isolation segments does not restrict routes by that field.
+type RouteFilter struct {
+	RouterGroupGuids  []string
+	IsolationSegments []string
+}
+
+// Skips returns why a route belongs to another router, or an empty string if
+// this router serves it.
+func (f RouteFilter) Skips(routeMapping apimodels.TcpRouteMapping) string {
+	if len(f.RouterGroupGuids) > 0 && !contains(f.RouterGroupGuids, routeMapping.RouterGroupGuid) {
+		return "router-group"
+	}
+	if len(f.IsolationSegments) > 0 && !contains(f.IsolationSegments, routeMapping.IsolationSegment) {
+		return "isolation-segment"
+	}
+	return ""
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"code.cloudfoundry.org/cf-tcp-router/configurer"
+	"code.cloudfoundry.org/cf-tcp-router/metrics_reporter"
 	"code.cloudfoundry.org/cf-tcp-router/models"
+	"code.cloudfoundry.org/cf-tcp-router/retry"
 	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/lager/v3"
 	routing_api "code.cloudfoundry.org/routing-api"
@@ -15,6 +18,16 @@ import (
 	"code.cloudfoundry.org/routing-api/uaaclient"
 )
 
+var (
+	prunedStaleBackends = metrics_reporter.Counter("PrunedStaleBackends")
+	routingTableSize    = metrics_reporter.Value("RoutingTableSize")
+	syncDuration        = metrics_reporter.DurationMs("SyncDurationMs")
+	skippedRoutes       = metrics_reporter.Value("SkippedRoutes")
+	skippedRouteEvents  = metrics_reporter.Counter("SkippedRouteEvents")
+
+	consecutiveSyncFailures = metrics_reporter.Value("ConsecutiveSyncFailures")
+)
+
 //go:generate counterfeiter -o fakes/fake_updater.go . Updater
 type Updater interface {
 	HandleEvent(event routing_api.TcpEvent) error
@@ -22,13 +35,19 @@ type Updater interface {
 	Syncing() bool
 	PruneStaleRoutes()
 	Drain() error
+	IsDraining() bool
+	RoutingTable() models.RoutingTable
+	LastSyncTime() time.Time
+	Synced() bool
+	Ready() bool
+	SetDrainWaitDuration(drainWaitDuration time.Duration)
+	Reconfigure() error
 }
 
 type updater struct {
 	logger            lager.Logger
 	routingTable      *models.RoutingTable
 	configurer        configurer.RouterConfigurer
-	syncing           bool
 	routingAPIClient  routing_api.Client
 	uaaTokenFetcher   uaaclient.TokenFetcher
 	cachedEvents      []routing_api.TcpEvent
@@ -36,23 +55,34 @@ type updater struct {
 	klock             clock.Clock
 	defaultTTL        int
 	drainWaitDuration time.Duration
-	isDraining        bool
+	routeFilter       RouteFilter
+	syncBackoff       *retry.Backoff
+	// cancelSync is set while a sync is running and stops its retries
+	cancelSync context.CancelFunc
+
+	// accessed atomically; syncing is only changed while holding the lock
+	syncing      int32
+	lastSyncTime int64
+	draining     int32
+	synced       int32
+	ready        int32
 }
 
 func NewUpdater(logger lager.Logger, routingTable *models.RoutingTable, configurer configurer.RouterConfigurer,
-	routingAPIClient routing_api.Client, uaaTokenFetcher uaaclient.TokenFetcher, klock clock.Clock, defaultTTL int, drainWaitDuration time.Duration) Updater {
+	routingAPIClient routing_api.Client, uaaTokenFetcher uaaclient.TokenFetcher, klock clock.Clock, defaultTTL int, drainWaitDuration time.Duration, routeFilter RouteFilter, retryPolicy retry.Policy) Updater {
 	return &updater{
 		logger:            logger,
 		routingTable:      routingTable,
 		configurer:        configurer,
 		lock:              new(sync.Mutex),
-		syncing:           false,
 		routingAPIClient:  routingAPIClient,
 		uaaTokenFetcher:   uaaTokenFetcher,
 		cachedEvents:      nil,
 		klock:             klock,
 		defaultTTL:        defaultTTL,
 		drainWaitDuration: drainWaitDuration,
+		routeFilter:       routeFilter,
+		syncBackoff:       retry.NewBackoff(retryPolicy, consecutiveSyncFailures),
 	}
 }
 
@@ -66,78 +96,167 @@ func (u *updater) PruneStaleRoutes() {
 	}()
 
 	u.lock.Lock()
-	u.routingTable.PruneEntries(u.defaultTTL)
+	pruned := u.routingTable.PruneEntries(u.defaultTTL)
+	if len(pruned) == 0 {
+		return
+	}
+
+	numPruned := 0
+	for routingKey, backends := range pruned {
+		for _, backend := range backends {
+			logger.Info("pruned-stale-backend", lager.Data{"routing-key": routingKey, "backend": backend})
+		}
+		numPruned += len(backends)
+	}
+	prunedStaleBackends.Add(uint64(numPruned))
+	routingTableSize.Send(uint64(u.routingTable.Size()))
+
+	logger.Debug("calling-configurer", lager.Data{"num-pruned": numPruned})
+	err := u.configurer.Configure(*u.routingTable, u.IsDraining())
+	if err != nil {
+		logger.Error("failed-to-configure-after-pruning", err)
+	}
 }
 
 func (u *updater) Sync() {
 	logger := u.logger.Session("bulk-sync")
 	logger.Debug("starting")
+	start := u.klock.Now()
+
+	// Retries can keep a sync running past the next sync request
+	u.lock.Lock()
+	if u.cancelSync != nil {
+		u.lock.Unlock()
+		logger.Info("sync-already-in-progress")
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	u.cancelSync = cancel
+	u.lock.Unlock()
+
+	defer func() {
+		u.lock.Lock()
+		u.cancelSync = nil
+		u.lock.Unlock()
+		cancel()
+		syncDuration.Send(uint64(u.klock.Since(start).Milliseconds()))
+		logger.Debug("completed")
+	}()
+
+	useCachedToken := true
+	err := u.syncBackoff.Do(ctx, u.klock, func() error {
+		return u.syncAttempt(logger, &useCachedToken)
+	})
+	if err != nil {
+		logger.Error("sync-failed", err, lager.Data{"consecutive-failures": u.syncBackoff.ConsecutiveFailures()})
+	}
+}
+
+// syncAttempt fetches the routes from routing api and applies them. Events
+// received meanwhile are cached and applied afterwards, also when the attempt
+// fails, so that route changes are not held back while the sync waits to
+// retry.
+func (u *updater) syncAttempt(logger lager.Logger, useCachedToken *bool) error {
+	u.lock.Lock()
+	atomic.StoreInt32(&u.syncing, 1)
+	u.cachedEvents = []routing_api.TcpEvent{}
+	u.lock.Unlock()
 
 	tableChanged := false
+	fetched := false
 	defer func() {
 		u.lock.Lock()
 		if u.applyCachedEvents(logger) {
 			tableChanged = true
 		}
-		if tableChanged {
-			_ = u.configurer.Configure(*u.routingTable, u.isDraining)
+		if fetched {
+			atomic.StoreInt32(&u.synced, 1)
+		}
+		// The tcp load balancer is reconfigured on the first sync even when
+		// the table did not change, so that the reload script learns that the
+		// router is ready. The router is only ready once that has been
+		// applied, so it must not wait for a batch of changes.
+		becameReady := fetched && !u.Ready()
+		if becameReady {
+			err := u.configureImmediately()
+			if err != nil {
+				logger.Error("failed-to-apply-first-sync", err)
+			} else {
+				atomic.StoreInt32(&u.ready, 1)
+				logger.Info("ready", lager.Data{"size": u.routingTable.Size()})
+			}
+		} else if tableChanged {
+			_ = u.configurer.Configure(*u.routingTable, u.IsDraining())
+		}
+		if tableChanged || becameReady {
 			logger.Debug("applied-fetched-routes-to-routing-table", lager.Data{"size": u.routingTable.Size()})
 		}
-		u.syncing = false
+		routingTableSize.Send(uint64(u.routingTable.Size()))
+		atomic.StoreInt32(&u.syncing, 0)
 		u.cachedEvents = nil
 		u.lock.Unlock()
-		logger.Debug("completed")
 	}()
 
-	u.lock.Lock()
-	u.syncing = true
-	u.cachedEvents = []routing_api.TcpEvent{}
-	u.lock.Unlock()
-
-	useCachedToken := true
-	var err error
 	var tcpRouteMappings []apimodels.TcpRouteMapping
+	var err error
 	for count := 0; count < 2; count++ {
-		token, tokenErr := u.uaaTokenFetcher.FetchToken(context.Background(), !useCachedToken)
+		token, tokenErr := u.uaaTokenFetcher.FetchToken(context.Background(), !*useCachedToken)
 		if tokenErr != nil {
 			logger.Error("error-fetching-token", tokenErr)
-			return
+			return tokenErr
 		}
 		u.routingAPIClient.SetToken(token.AccessToken)
 		tcpRouteMappings, err = u.routingAPIClient.TcpRouteMappings()
-		if err != nil {
-			logger.Error("error-fetching-routes", err)
-			if err.Error() == "unauthorized" {
-				useCachedToken = false
-				logger.Info("retrying-sync")
-			} else {
-				return
-			}
-		} else {
+		if err == nil {
 			break
 		}
+		logger.Error("error-fetching-routes", err)
+		if err.Error() != "unauthorized" {
+			return err
+		}
+		*useCachedToken = false
+		logger.Info("retrying-sync")
+	}
+	if err != nil {
+		return err
 	}
 	logger.Debug("fetched-tcp-routes", lager.Data{"num-routes": len(tcpRouteMappings)})
+	fetched = true
 
-	if err == nil {
-		freshRoutingTable := models.NewRoutingTableWithSession(logger, "fresh-routing-table")
+	// Hold the lock while changing the table so readers of RoutingTable() see a consistent table
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	atomic.StoreInt64(&u.lastSyncTime, u.klock.Now().UnixNano())
 
-		for _, routeMapping := range tcpRouteMappings {
-			routingKey, backendServerInfo := u.toRoutingTableEntry(logger, routeMapping)
-			logger.Debug("creating-routing-table-entry", lager.Data{"key": routingKey, "value": backendServerInfo})
-			if u.routingTable.UpsertBackendServerKey(routingKey, backendServerInfo) {
-				tableChanged = true
-				logger.Debug("change-detected-for-endpoint", lager.Data{"key": routingKey, "value": backendServerInfo})
-			}
-			freshRoutingTable.UpsertBackendServerKey(routingKey, backendServerInfo)
-		}
+	freshRoutingTable := models.NewRoutingTableWithSession(logger, "fresh-routing-table")
 
-		if freshRoutingTable.Size() != u.routingTable.Size() {
+	numSkipped := 0
+	for _, routeMapping := range tcpRouteMappings {
+		if reason := u.routeFilter.Skips(routeMapping); reason != "" {
+			logger.Debug("skipping-route", lager.Data{"tcp-route": routeMapping, "reason": reason})
+			numSkipped++
+			continue
+		}
+		routingKey, backendServerInfo := u.toRoutingTableEntry(logger, routeMapping)
+		logger.Debug("creating-routing-table-entry", lager.Data{"key": routingKey, "value": backendServerInfo})
+		if u.routingTable.UpsertBackendServerKey(routingKey, backendServerInfo) {
 			tableChanged = true
-			logger.Debug("routing-table-size-discrepency", lager.Data{"old-table-entries": u.routingTable.Size(), "new-table-entries": freshRoutingTable.Size()})
-			u.routingTable.Entries = freshRoutingTable.Entries
+			logger.Debug("change-detected-for-endpoint", lager.Data{"key": routingKey, "value": backendServerInfo})
 		}
+		freshRoutingTable.UpsertBackendServerKey(routingKey, backendServerInfo)
+	}
+
+	if numSkipped > 0 {
+		logger.Info("skipped-routes-served-by-other-routers", lager.Data{"num-skipped": numSkipped})
+	}
+	skippedRoutes.Send(uint64(numSkipped))
+
+	if freshRoutingTable.Size() != u.routingTable.Size() {
+		tableChanged = true
+		logger.Debug("routing-table-size-discrepency", lager.Data{"old-table-entries": u.routingTable.Size(), "new-table-entries": freshRoutingTable.Size()})
+		u.routingTable.Entries = freshRoutingTable.Entries
 	}
+	return nil
 }
 
 func (u *updater) applyCachedEvents(logger lager.Logger) bool {
@@ -153,23 +272,90 @@ func (u *updater) applyCachedEvents(logger lager.Logger) bool {
 	return tableChanged
 }
 
+// Syncing does not take the updater's lock, so that the admin api is not
+// held up while the updater reconfigures.
 func (u *updater) Syncing() bool {
+	return atomic.LoadInt32(&u.syncing) == 1
+}
+
+// IsDraining does not take the updater's lock, so that health checks are not
+// held up while the updater reconfigures.
+func (u *updater) IsDraining() bool {
+	return atomic.LoadInt32(&u.draining) == 1
+}
+
+// RoutingTable returns a copy of the routing table that is safe to read while
+// the updater keeps applying changes.
+func (u *updater) RoutingTable() models.RoutingTable {
 	u.lock.Lock()
 	defer u.lock.Unlock()
-	return u.syncing
+	return u.routingTable.Copy()
 }
 
-func (u *updater) IsDraining() bool {
+// LastSyncTime returns when routes were last fetched from routing api, or the
+// zero time if no sync has succeeded yet. Like Syncing, it does not take the
+// updater's lock.
+func (u *updater) LastSyncTime() time.Time {
+	lastSyncTime := atomic.LoadInt64(&u.lastSyncTime)
+	if lastSyncTime == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, lastSyncTime)
+}
+
+// Synced reports whether the routing table holds the routes of a full sync,
+// which may not have been applied to the tcp load balancer yet. Synced does
+// not take the updater's lock, so the configurer can call it while the updater
+// reconfigures.
+func (u *updater) Synced() bool {
+	return atomic.LoadInt32(&u.synced) == 1
+}
+
+// Ready reports whether the routes of a full sync have been applied to the tcp
+// load balancer. It stays true once the first sync has been applied.
+func (u *updater) Ready() bool {
+	return atomic.LoadInt32(&u.ready) == 1
+}
+
+// SetDrainWaitDuration changes how long Drain waits after reconfiguring the
+// tcp load balancer. A drain that is already waiting is not affected.
+func (u *updater) SetDrainWaitDuration(drainWaitDuration time.Duration) {
 	u.lock.Lock()
 	defer u.lock.Unlock()
-	return u.isDraining
+	u.drainWaitDuration = drainWaitDuration
+}
+
+// Reconfigure applies the current routing table again, e.g. after settings
+// used to render the tcp load balancer config have changed. The table is
+// applied before Reconfigure returns, even when the configurer batches
+// changes, so the error tells whether the new settings are in use.
+func (u *updater) Reconfigure() error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.logger.Debug("calling-configurer")
+	return u.configureImmediately()
+}
+
+// configureImmediately applies the routing table without waiting for a batch
+// of changes. Callers must hold the lock.
+func (u *updater) configureImmediately() error {
+	if immediate, ok := u.configurer.(configurer.ImmediateConfigurer); ok {
+		return immediate.ConfigureImmediately(*u.routingTable, u.IsDraining())
+	}
+	return u.configurer.Configure(*u.routingTable, u.IsDraining())
 }
 
 func (u *updater) HandleEvent(event routing_api.TcpEvent) error {
+	if reason := u.routeFilter.Skips(event.TcpRouteMapping); reason != "" {
+		u.logger.Debug("skipping-event", lager.Data{"event": event, "reason": reason})
+		skippedRouteEvents.Add(1)
+		return nil
+	}
+
 	u.lock.Lock()
 	defer u.lock.Unlock()
 
-	if u.syncing {
+	if u.Syncing() {
 		u.logger.Debug("caching-events")
 		u.cachedEvents = append(u.cachedEvents, event)
 	} else {
@@ -179,22 +365,27 @@ func (u *updater) HandleEvent(event routing_api.TcpEvent) error {
 	return nil
 }
 
+// Drain reconfigures the tcp load balancer to fail its health check and waits
+// for the drain wait. A sync in progress is allowed to finish its current
+// attempt to fetch routes, but does not retry, so that a routing api outage
+// does not hold up the drain.
 func (u *updater) Drain() error {
+	u.lock.Lock()
+	if u.cancelSync != nil {
+		u.cancelSync()
+	}
+	u.lock.Unlock()
 
 	for u.Syncing() {
 		u.logger.Debug("waiting-for-sync-to-finish-before-starting-drain")
 		time.Sleep(100 * time.Millisecond)
 	}
 
-	if u.IsDraining() {
+	if !atomic.CompareAndSwapInt32(&u.draining, 0, 1) {
 		u.logger.Debug("drain-already-in-progress")
 		return nil
 	}
 
-	u.lock.Lock()
-	u.isDraining = true
-	u.lock.Unlock()
-
 	u.logger.Info("drain-started")
 	err := u.configurer.Configure(*u.routingTable, u.IsDraining())
 	if err != nil {
@@ -203,8 +394,12 @@ func (u *updater) Drain() error {
 		return err
 	}
 
-	u.logger.Debug("starting-drain-wait", lager.Data{"drain-wait-period": u.drainWaitDuration})
-	time.Sleep(u.drainWaitDuration)
+	u.lock.Lock()
+	drainWaitDuration := u.drainWaitDuration
+	u.lock.Unlock()
+
+	u.logger.Debug("starting-drain-wait", lager.Data{"drain-wait-period": drainWaitDuration})
+	time.Sleep(drainWaitDuration)
 	u.logger.Debug("finished-drain-wait")
 
 	return nil
@@ -258,9 +453,10 @@ func (u *updater) toRoutingTableEntry(logger lager.Logger, routeMapping apimodel
 func (u *updater) handleUpsert(logger lager.Logger, routeMapping apimodels.TcpRouteMapping) (bool, error) {
 	routingKey, backendServerInfo := u.toRoutingTableEntry(logger, routeMapping)
 	tableChanged := u.routingTable.UpsertBackendServerKey(routingKey, backendServerInfo)
-	if tableChanged && !u.syncing {
+	if tableChanged && !u.Syncing() {
+		routingTableSize.Send(uint64(u.routingTable.Size()))
 		logger.Debug("calling-configurer")
-		return true, u.configurer.Configure(*u.routingTable, u.isDraining) // called from HandleEvent which already has a lock, so don't need to use IsDraining() here
+		return true, u.configurer.Configure(*u.routingTable, u.IsDraining())
 	}
 
 	return tableChanged, nil
@@ -270,9 +466,10 @@ func (u *updater) handleDelete(logger lager.Logger, routeMapping apimodels.TcpRo
 	routingKey, backendServerInfo := u.toRoutingTableEntry(logger, routeMapping)
 
 	tableChanged := u.routingTable.DeleteBackendServerKey(routingKey, backendServerInfo)
-	if tableChanged && !u.syncing {
+	if tableChanged && !u.Syncing() {
+		routingTableSize.Send(uint64(u.routingTable.Size()))
 		logger.Debug("calling-configurer")
-		return true, u.configurer.Configure(*u.routingTable, u.isDraining) // called from HandleEvent which already has a lock, so don't need to use IsDraining() here
+		return true, u.configurer.Configure(*u.routingTable, u.IsDraining())
 	}
 
 	return tableChanged, nil
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"time"
 
+	"code.cloudfoundry.org/cf-tcp-router/configurer"
 	"code.cloudfoundry.org/cf-tcp-router/configurer/fakes"
 	"code.cloudfoundry.org/cf-tcp-router/models"
+	"code.cloudfoundry.org/cf-tcp-router/retry"
 	"code.cloudfoundry.org/cf-tcp-router/routing_table"
 	"code.cloudfoundry.org/cf-tcp-router/testutil"
 	"code.cloudfoundry.org/clock/fakeclock"
@@ -14,6 +16,8 @@ import (
 	"code.cloudfoundry.org/routing-api/fake_routing_api"
 	apimodels "code.cloudfoundry.org/routing-api/models"
 	test_uaa_client "code.cloudfoundry.org/routing-api/uaaclient/fakes"
+	"github.com/cloudfoundry/dropsonde/metric_sender/fake"
+	"github.com/cloudfoundry/dropsonde/metrics"
 	"golang.org/x/oauth2"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -49,6 +53,8 @@ var _ = Describe("Updater", func() {
 		modificationTag            apimodels.ModificationTag
 		fakeClock                  *fakeclock.FakeClock
 		drainWaitDuration          time.Duration
+		routeFilter                routing_table.RouteFilter
+		retryPolicy                retry.Policy
 	)
 
 	verifyRoutingTableEntry := func(key models.RoutingKey, entry models.RoutingTableEntry) {
@@ -71,10 +77,12 @@ var _ = Describe("Updater", func() {
 		tmpRoutingTable := models.NewRoutingTable(logger)
 		routingTable = &tmpRoutingTable
 		fakeClock = fakeclock.NewFakeClock(time.Now())
+		routeFilter = routing_table.RouteFilter{}
+		retryPolicy = retry.Policy{InitialDelay: time.Second, MaxDelay: time.Second, Multiplier: 1, MaxAttempts: 1}
 	})
 
 	JustBeforeEach(func() {
-		updater = routing_table.NewUpdater(logger, routingTable, fakeConfigurer, fakeRoutingApiClient, fakeTokenFetcher, fakeClock, defaultTTL, drainWaitDuration)
+		updater = routing_table.NewUpdater(logger, routingTable, fakeConfigurer, fakeRoutingApiClient, fakeTokenFetcher, fakeClock, defaultTTL, drainWaitDuration, routeFilter, retryPolicy)
 	})
 
 	Describe("HandleEvent", func() {
@@ -108,7 +116,47 @@ var _ = Describe("Updater", func() {
 		})
 
 		JustBeforeEach(func() {
-			updater = routing_table.NewUpdater(logger, routingTable, fakeConfigurer, fakeRoutingApiClient, fakeTokenFetcher, fakeClock, defaultTTL, drainWaitDuration)
+			updater = routing_table.NewUpdater(logger, routingTable, fakeConfigurer, fakeRoutingApiClient, fakeTokenFetcher, fakeClock, defaultTTL, drainWaitDuration, routeFilter, retryPolicy)
+		})
+
+		Context("when an event for a route served by another router is received", func() {
+			BeforeEach(func() {
+				mapping := apimodels.NewTcpRouteMapping(routerGroupGuid, externalPort4, "some-ip-4", 2346, 0, "", nil, ttl, modificationTag)
+				mapping.IsolationSegment = "is2"
+				tcpEvent = routing_api.TcpEvent{
+					TcpRouteMapping: mapping,
+					Action:          "Upsert",
+				}
+			})
+
+			It("skips events for other router groups", func() {
+				routeFilter = routing_table.RouteFilter{RouterGroupGuids: []string{"other-router-group"}}
+				updater = routing_table.NewUpdater(logger, routingTable, fakeConfigurer, fakeRoutingApiClient, fakeTokenFetcher, fakeClock, defaultTTL, drainWaitDuration, routeFilter, retryPolicy)
+
+				Expect(updater.HandleEvent(tcpEvent)).To(Succeed())
+				Expect(routingTable.Get(models.RoutingKey{Port: externalPort4})).To(BeZero())
+				Expect(fakeConfigurer.ConfigureCallCount()).To(Equal(0))
+			})
+
+			It("skips events for other isolation segments and counts them", func() {
+				sender := fake.NewFakeMetricSender()
+				metrics.Initialize(sender, nil)
+				routeFilter = routing_table.RouteFilter{IsolationSegments: []string{"is1"}}
+				updater = routing_table.NewUpdater(logger, routingTable, fakeConfigurer, fakeRoutingApiClient, fakeTokenFetcher, fakeClock, defaultTTL, drainWaitDuration, routeFilter, retryPolicy)
+
+				Expect(updater.HandleEvent(tcpEvent)).To(Succeed())
+				Expect(routingTable.Get(models.RoutingKey{Port: externalPort4})).To(BeZero())
+				Expect(fakeConfigurer.ConfigureCallCount()).To(Equal(0))
+				Expect(sender.GetCounter("SkippedRouteEvents")).To(Equal(uint64(1)))
+			})
+
+			It("handles events for the isolation segments of this router", func() {
+				routeFilter = routing_table.RouteFilter{RouterGroupGuids: []string{routerGroupGuid}, IsolationSegments: []string{"is1", "is2"}}
+				updater = routing_table.NewUpdater(logger, routingTable, fakeConfigurer, fakeRoutingApiClient, fakeTokenFetcher, fakeClock, defaultTTL, drainWaitDuration, routeFilter, retryPolicy)
+
+				Expect(updater.HandleEvent(tcpEvent)).To(Succeed())
+				Expect(fakeConfigurer.ConfigureCallCount()).To(Equal(1))
+			})
 		})
 
 		Context("when Upsert event is received", func() {
@@ -545,6 +593,120 @@ var _ = Describe("Updater", func() {
 				Expect(fakeConfigurer.ConfigureCallCount()).To(Equal(1))
 			})
 
+			Context("when some routes are served by other routers", func() {
+				BeforeEach(func() {
+					routeFilter = routing_table.RouteFilter{RouterGroupGuids: []string{routerGroupGuid}, IsolationSegments: []string{"is1"}}
+					for i := range tcpMappings {
+						tcpMappings[i].IsolationSegment = "is1"
+					}
+					tcpMappings[1].IsolationSegment = "is2"
+					tcpMappings[3].RouterGroupGuid = "other-router-group"
+					fakeRoutingApiClient.TcpRouteMappingsReturns(tcpMappings, nil)
+				})
+
+				It("only adds the routes of this router and counts the others", func() {
+					sender := fake.NewFakeMetricSender()
+					metrics.Initialize(sender, nil)
+
+					go invokeSync(doneChannel)
+					Eventually(doneChannel).Should(BeClosed())
+
+					verifyRoutingTableEntry(models.RoutingKey{Port: externalPort1}, models.NewRoutingTableEntry(
+						[]models.BackendServerInfo{{Address: "some-ip-1", Port: 61000, ModificationTag: modificationTag, TTL: ttl}},
+					))
+					verifyRoutingTableEntry(models.RoutingKey{Port: externalPort2}, models.NewRoutingTableEntry(
+						[]models.BackendServerInfo{{Address: "some-ip-3", Port: 60000, ModificationTag: modificationTag, TTL: ttl}},
+					))
+					Expect(routingTable.Get(models.RoutingKey{Port: externalPort1}).Backends).To(HaveLen(1))
+					Expect(routingTable.Get(models.RoutingKey{Port: externalPort2}).Backends).To(HaveLen(1))
+					Expect(sender.GetValue("SkippedRoutes").Value).To(Equal(float64(2)))
+					Expect(logger).To(gbytes.Say("skipped-routes-served-by-other-routers"))
+				})
+			})
+
+			It("emits the routing table size and the sync duration", func() {
+				sender := fake.NewFakeMetricSender()
+				metrics.Initialize(sender, nil)
+
+				go invokeSync(doneChannel)
+				Eventually(doneChannel).Should(BeClosed())
+
+				Expect(sender.GetValue("RoutingTableSize")).To(Equal(fake.Metric{Value: float64(2), Unit: "Metric"}))
+				Expect(sender.GetValue("SyncDurationMs").Unit).To(Equal("ms"))
+			})
+
+			It("records the time of the sync", func() {
+				Expect(updater.LastSyncTime().IsZero()).To(BeTrue())
+				go invokeSync(doneChannel)
+				Eventually(doneChannel).Should(BeClosed())
+
+				Expect(updater.LastSyncTime()).To(BeTemporally("==", fakeClock.Now()))
+			})
+
+			It("becomes ready once the routes have been applied", func() {
+				Expect(updater.Synced()).To(BeFalse())
+				Expect(updater.Ready()).To(BeFalse())
+				fakeConfigurer.ConfigureStub = func(models.RoutingTable, bool) error {
+					Expect(updater.Synced()).To(BeTrue())
+					Expect(updater.Ready()).To(BeFalse())
+					return nil
+				}
+				go invokeSync(doneChannel)
+				Eventually(doneChannel).Should(BeClosed())
+
+				Expect(updater.Ready()).To(BeTrue())
+				Expect(logger).To(gbytes.Say("ready"))
+			})
+
+			Context("when the configurer batches changes", func() {
+				JustBeforeEach(func() {
+					batchingConfigurer := configurer.NewBatchingConfigurer(logger, fakeConfigurer, fakeClock, time.Minute, time.Minute)
+					updater = routing_table.NewUpdater(logger, routingTable, batchingConfigurer, fakeRoutingApiClient, fakeTokenFetcher, fakeClock, defaultTTL, drainWaitDuration, routeFilter, retryPolicy)
+				})
+
+				It("becomes ready without waiting for the batch", func() {
+					updater.Sync()
+					Expect(fakeConfigurer.ConfigureCallCount()).To(Equal(1))
+					Expect(updater.Ready()).To(BeTrue())
+				})
+
+				It("does not become ready when applying the routes fails", func() {
+					fakeConfigurer.ConfigureReturns(errors.New("boom"))
+					updater.Sync()
+					Expect(fakeConfigurer.ConfigureCallCount()).To(Equal(1))
+					Expect(updater.Ready()).To(BeFalse())
+					Expect(logger).To(gbytes.Say("failed-to-apply-first-sync.*boom"))
+				})
+			})
+
+			Context("when the configurer fails to apply the first sync", func() {
+				BeforeEach(func() {
+					fakeConfigurer.ConfigureReturnsOnCall(0, errors.New("boom"))
+				})
+
+				It("does not become ready until a sync has been applied", func() {
+					go invokeSync(doneChannel)
+					Eventually(doneChannel).Should(BeClosed())
+					Expect(updater.Ready()).To(BeFalse())
+					Expect(logger).To(gbytes.Say("failed-to-apply-first-sync"))
+
+					updater.Sync()
+					Expect(fakeConfigurer.ConfigureCallCount()).To(Equal(2))
+					Expect(updater.Ready()).To(BeTrue())
+				})
+			})
+
+			It("exposes a copy of the synced routing table", func() {
+				go invokeSync(doneChannel)
+				Eventually(doneChannel).Should(BeClosed())
+
+				snapshot := updater.RoutingTable()
+				Expect(snapshot.Entries).To(Equal(routingTable.Entries))
+
+				delete(snapshot.Entries, models.RoutingKey{Port: externalPort1})
+				Expect(routingTable.Entries).To(HaveKey(models.RoutingKey{Port: externalPort1}))
+			})
+
 			Context("when there are no changes to the routing table", func() {
 				BeforeEach(func() {
 					expectedRoutingTableEntry1 := models.NewRoutingTableEntry(
@@ -564,7 +726,7 @@ var _ = Describe("Updater", func() {
 					routingTable.Set(models.RoutingKey{Port: externalPort2}, expectedRoutingTableEntry2)
 				})
 
-				It("does not call the configurer", func() {
+				It("only calls the configurer to report that the router is ready", func() {
 					Expect(routingTable.Size()).To(Equal(2))
 					go invokeSync(doneChannel)
 					Eventually(doneChannel).Should(BeClosed())
@@ -572,7 +734,10 @@ var _ = Describe("Updater", func() {
 					Expect(fakeTokenFetcher.FetchTokenCallCount()).To(Equal(1))
 					Expect(fakeRoutingApiClient.TcpRouteMappingsCallCount()).To(Equal(1))
 					Expect(routingTable.Size()).To(Equal(2))
-					Expect(fakeConfigurer.ConfigureCallCount()).To(Equal(0))
+					Expect(fakeConfigurer.ConfigureCallCount()).To(Equal(1))
+
+					updater.Sync()
+					Expect(fakeConfigurer.ConfigureCallCount()).To(Equal(1))
 				})
 			})
 
@@ -812,7 +977,10 @@ var _ = Describe("Updater", func() {
 
 							targetBackend = routingTable.Entries[models.RoutingKey{Port: externalPort1}].Backends[models.BackendServerKey{Address: "some-ip-1", Port: 61000}]
 							Expect(targetBackend.ModificationTag.Index).To(Equal(uint32(1))) // ensure the routing table took the change
-							Expect(fakeConfigurer.ConfigureCallCount()).To(Equal(0))         // ensure it didn't reload haproxy
+							Expect(fakeConfigurer.ConfigureCallCount()).To(Equal(1))         // ensure it only reloaded haproxy to report that the router is ready
+							_, forceHealthCheckToFail := fakeConfigurer.ConfigureArgsForCall(0)
+							Expect(forceHealthCheckToFail).To(BeFalse())
+							Expect(updater.Ready()).To(BeTrue())
 						})
 					})
 					Context("and the events modify more than just modification tags", func() {
@@ -887,6 +1055,64 @@ var _ = Describe("Updater", func() {
 					)
 					verifyRoutingTableEntry(models.RoutingKey{Port: externalPort1}, expectedRoutingTableEntry1)
 				})
+
+				It("does not record a sync time", func() {
+					go invokeSync(doneChannel)
+					Eventually(doneChannel).Should(BeClosed())
+
+					Expect(updater.LastSyncTime().IsZero()).To(BeTrue())
+					Expect(updater.Ready()).To(BeFalse())
+				})
+
+				Context("when the retry policy allows more attempts", func() {
+					BeforeEach(func() {
+						retryPolicy = retry.Policy{InitialDelay: time.Second, MaxDelay: 10 * time.Second, Multiplier: 2, MaxAttempts: 3}
+						fakeRoutingApiClient.TcpRouteMappingsReturnsOnCall(2, tcpMappings, nil)
+					})
+
+					It("backs off between attempts until routes are fetched", func() {
+						sender := fake.NewFakeMetricSender()
+						metrics.Initialize(sender, nil)
+
+						go invokeSync(doneChannel)
+						fakeClock.WaitForWatcherAndIncrement(time.Second)
+						Expect(sender.GetValue("ConsecutiveSyncFailures").Value).To(Equal(float64(1)))
+						fakeClock.WaitForWatcherAndIncrement(2 * time.Second)
+						Eventually(doneChannel).Should(BeClosed())
+
+						Expect(fakeRoutingApiClient.TcpRouteMappingsCallCount()).To(Equal(3))
+						Expect(routingTable.Size()).To(Equal(2))
+						Expect(sender.GetValue("ConsecutiveSyncFailures").Value).To(Equal(float64(0)))
+					})
+
+					It("applies events received while it waits to retry", func() {
+						go invokeSync(doneChannel)
+						Eventually(fakeClock.WatcherCount).Should(Equal(1))
+						Expect(updater.Syncing()).To(BeFalse())
+
+						mapping := apimodels.NewTcpRouteMapping(routerGroupGuid, externalPort4, "some-ip-9", 61000, 0, "", nil, ttl, modificationTag)
+						Expect(updater.HandleEvent(routing_api.TcpEvent{TcpRouteMapping: mapping, Action: "Upsert"})).To(Succeed())
+						Expect(fakeConfigurer.ConfigureCallCount()).To(Equal(1))
+						configuredTable, _ := fakeConfigurer.ConfigureArgsForCall(0)
+						Expect(configuredTable.Get(models.RoutingKey{Port: externalPort4})).NotTo(BeZero())
+
+						fakeClock.WaitForWatcherAndIncrement(time.Second)
+						fakeClock.WaitForWatcherAndIncrement(2 * time.Second)
+						Eventually(doneChannel).Should(BeClosed())
+					})
+
+					It("ignores sync requests while it is retrying", func() {
+						go invokeSync(doneChannel)
+						fakeClock.WaitForWatcherAndIncrement(time.Second)
+
+						updater.Sync()
+						Expect(logger).To(gbytes.Say("sync-already-in-progress"))
+
+						fakeClock.WaitForWatcherAndIncrement(2 * time.Second)
+						Eventually(doneChannel).Should(BeClosed())
+						Expect(fakeRoutingApiClient.TcpRouteMappingsCallCount()).To(Equal(3))
+					})
+				})
 			})
 
 			Context("unauthorized", func() {
@@ -988,7 +1214,7 @@ var _ = Describe("Updater", func() {
 		})
 
 		JustBeforeEach(func() {
-			updater = routing_table.NewUpdater(logger, routingTable, fakeConfigurer, fakeRoutingApiClient, fakeTokenFetcher, fakeClock, defaultTTL, drainWaitDuration)
+			updater = routing_table.NewUpdater(logger, routingTable, fakeConfigurer, fakeRoutingApiClient, fakeTokenFetcher, fakeClock, defaultTTL, drainWaitDuration, routeFilter, retryPolicy)
 		})
 
 		Context("when none of the routes are stale", func() {
@@ -1011,15 +1237,27 @@ var _ = Describe("Updater", func() {
 				)
 				verifyRoutingTableEntry(models.RoutingKey{Port: externalPort2}, expectedRoutingTableEntry2)
 			})
+
+			It("does not reconfigure the load balancer", func() {
+				updater.PruneStaleRoutes()
+				Expect(fakeConfigurer.ConfigureCallCount()).To(Equal(0))
+			})
 		})
 
 		Context("when some routes are stale", func() {
+			var sender *fake.FakeMetricSender
+
+			BeforeEach(func() {
+				sender = fake.NewFakeMetricSender()
+				metrics.Initialize(sender, nil)
+			})
+
 			BeforeEach(func() {
 				fakeClock.IncrementBySeconds(65)
 			})
 
 			JustBeforeEach(func() {
-				updater = routing_table.NewUpdater(logger, routingTable, fakeConfigurer, fakeRoutingApiClient, fakeTokenFetcher, fakeClock, 40, drainWaitDuration)
+				updater = routing_table.NewUpdater(logger, routingTable, fakeConfigurer, fakeRoutingApiClient, fakeTokenFetcher, fakeClock, 40, drainWaitDuration, routeFilter, retryPolicy)
 			})
 
 			It("prunes those routes", func() {
@@ -1034,6 +1272,93 @@ var _ = Describe("Updater", func() {
 				)
 				verifyRoutingTableEntry(models.RoutingKey{Port: externalPort2}, expectedRoutingTableEntry2)
 			})
+
+			It("reconfigures the load balancer once with the pruned table", func() {
+				updater.PruneStaleRoutes()
+				Expect(fakeConfigurer.ConfigureCallCount()).To(Equal(1))
+				configuredTable, forceHealthCheckToFail := fakeConfigurer.ConfigureArgsForCall(0)
+				Expect(configuredTable.Size()).To(Equal(1))
+				Expect(configuredTable.Entries).NotTo(HaveKey(models.RoutingKey{Port: externalPort1}))
+				Expect(forceHealthCheckToFail).To(BeFalse())
+			})
+
+			It("logs each pruned backend", func() {
+				updater.PruneStaleRoutes()
+				Expect(logger).To(gbytes.Say("pruned-stale-backend"))
+				Expect(logger).To(gbytes.Say("pruned-stale-backend"))
+			})
+
+			It("counts the pruned backends", func() {
+				updater.PruneStaleRoutes()
+				Expect(sender.GetCounter("PrunedStaleBackends")).To(Equal(uint64(2)))
+			})
+
+			Context("when Configurer returns an error", func() {
+				BeforeEach(func() {
+					fakeConfigurer.ConfigureReturns(errors.New("kaboom"))
+				})
+
+				It("logs the error", func() {
+					updater.PruneStaleRoutes()
+					Expect(logger).To(gbytes.Say("failed-to-configure-after-pruning"))
+				})
+			})
+		})
+	})
+
+	Describe("Reconfigure", func() {
+		It("configures the current routing table", func() {
+			routingTable.UpsertBackendServerKey(models.RoutingKey{Port: externalPort1}, models.BackendServerInfo{Address: "some-ip-1", Port: 61000})
+			Expect(updater.Reconfigure()).To(Succeed())
+
+			Expect(fakeConfigurer.ConfigureCallCount()).To(Equal(1))
+			configuredTable, drain := fakeConfigurer.ConfigureArgsForCall(0)
+			Expect(configuredTable.Size()).To(Equal(1))
+			Expect(drain).To(BeFalse())
+		})
+
+		Context("when the configurer fails", func() {
+			BeforeEach(func() {
+				fakeConfigurer.ConfigureReturns(errors.New("kaboom"))
+			})
+
+			It("returns the error", func() {
+				Expect(updater.Reconfigure()).To(MatchError("kaboom"))
+			})
+		})
+
+		Context("when the configurer batches changes", func() {
+			JustBeforeEach(func() {
+				batchingConfigurer := configurer.NewBatchingConfigurer(logger, fakeConfigurer, fakeClock, time.Minute, time.Minute)
+				updater = routing_table.NewUpdater(logger, routingTable, batchingConfigurer, fakeRoutingApiClient, fakeTokenFetcher, fakeClock, defaultTTL, drainWaitDuration, routeFilter, retryPolicy)
+			})
+
+			It("applies the routing table before returning", func() {
+				fakeConfigurer.ConfigureReturns(errors.New("kaboom"))
+				Expect(updater.Reconfigure()).To(MatchError("kaboom"))
+				Expect(fakeConfigurer.ConfigureCallCount()).To(Equal(1))
+			})
+		})
+	})
+
+	Describe("Syncing, LastSyncTime and IsDraining", func() {
+		It("do not wait for a reconfiguration in progress", func() {
+			release := make(chan struct{})
+			defer close(release)
+			fakeConfigurer.ConfigureStub = func(models.RoutingTable, bool) error {
+				<-release
+				return nil
+			}
+			go func() {
+				_ = updater.Reconfigure()
+			}()
+			Eventually(fakeConfigurer.ConfigureCallCount).Should(Equal(1))
+
+			busy := make(chan bool)
+			go func() {
+				busy <- updater.Syncing() || !updater.LastSyncTime().IsZero() || updater.IsDraining()
+			}()
+			Eventually(busy).Should(Receive(BeFalse()))
 		})
 	})
 
@@ -1088,7 +1413,40 @@ var _ = Describe("Updater", func() {
 				close(syncChannel)
 				Eventually(updater.Syncing).Should(BeFalse())
 				Eventually(logger).Should(gbytes.Say("drain-started"))
-				Eventually(fakeConfigurer.ConfigureCallCount()).Should(Equal(1))
+				// the first sync is applied before the drain
+				Eventually(fakeConfigurer.ConfigureCallCount).Should(Equal(2))
+				_, forceHealthCheckToFail := fakeConfigurer.ConfigureArgsForCall(1)
+				Expect(forceHealthCheckToFail).To(BeTrue())
+			})
+		})
+
+		Context("when a sync keeps failing", func() {
+			BeforeEach(func() {
+				retryPolicy.MaxAttempts = 0
+				fakeRoutingApiClient.TcpRouteMappingsReturns(nil, errors.New("routing api down"))
+			})
+
+			It("stops the retries of the sync and drains", func() {
+				syncDone := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					updater.Sync()
+					close(syncDone)
+				}()
+				Eventually(fakeClock.WatcherCount).Should(Equal(1))
+
+				drainErr := make(chan error)
+				go func() {
+					drainErr <- updater.Drain()
+				}()
+				Eventually(drainErr).Should(Receive(BeNil()))
+				Eventually(syncDone).Should(BeClosed())
+
+				Expect(logger).To(gbytes.Say("sync-failed.*context canceled"))
+				Expect(fakeRoutingApiClient.TcpRouteMappingsCallCount()).To(Equal(1))
+				Expect(fakeConfigurer.ConfigureCallCount()).To(Equal(1))
+				_, forceHealthCheckToFail := fakeConfigurer.ConfigureArgsForCall(0)
+				Expect(forceHealthCheckToFail).To(BeTrue())
 			})
 		})
 
@@ -1124,6 +1482,18 @@ var _ = Describe("Updater", func() {
 			})
 		})
 
+		Context("when the drain wait is changed", func() {
+			BeforeEach(func() {
+				drainWaitDuration = 10 * time.Second
+			})
+
+			It("waits for the new drain wait", func() {
+				updater.SetDrainWaitDuration(0)
+				Expect(updater.Drain()).To(Succeed())
+				Expect(logger).To(gbytes.Say("finished-drain-wait"))
+			})
+		})
+
 		Context("when Sync is called after drain", func() {
 			BeforeEach(func() {
 				tcpMappings := []apimodels.TcpRouteMapping{
@@ -7,38 +7,69 @@ import (
 	"syscall"
 	"time"
 
+	"code.cloudfoundry.org/cf-tcp-router/metrics_reporter"
+	"code.cloudfoundry.org/cf-tcp-router/retry"
 	"code.cloudfoundry.org/cf-tcp-router/routing_table"
+	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/lager/v3"
 	routing_api "code.cloudfoundry.org/routing-api"
 	"code.cloudfoundry.org/routing-api/uaaclient"
 	"github.com/tedsuo/ifrit"
 )
 
+var (
+	consecutiveSubscriptionFailures = metrics_reporter.Value("ConsecutiveSubscriptionFailures")
+	eventStreamConnected            = metrics_reporter.Value("EventStreamConnected")
+	eventStreamReconnects           = metrics_reporter.Counter("EventStreamReconnects")
+	eventStreamIdleResubscribes     = metrics_reporter.Counter("EventStreamIdleResubscribes")
+	eventStreamLag                  = metrics_reporter.DurationMs("EventStreamLagMs")
+	eventStreamGap                  = metrics_reporter.DurationMs("EventStreamGapMs")
+)
+
 type Watcher struct {
-	routingAPIClient          routing_api.Client
-	updater                   routing_table.Updater
-	uaaTokenFetcher           uaaclient.TokenFetcher
-	subscriptionRetryInterval int
-	syncChannel               chan struct{}
-	logger                    lager.Logger
-	process                   ifrit.Process
+	routingAPIClient routing_api.Client
+	updater          routing_table.Updater
+	uaaTokenFetcher  uaaclient.TokenFetcher
+	clock            clock.Clock
+	backoff          *retry.Backoff
+	idleTimeout      time.Duration
+	syncChannel      chan struct{}
+	logger           lager.Logger
+	process          ifrit.Process
+
+	// accessed atomically
+	connected     int32
+	lastEventTime int64
 }
 
+// New creates a Watcher that keeps retrying to subscribe to routing api
+// events with the delays of retryPolicy. The policy's MaxAttempts is ignored,
+// since the router cannot work without the event stream.
+//
+// Whenever the watcher subscribes after losing the event stream, or after an
+// attempt to subscribe failed, it requests a sync through syncChannel to pick
+// up the events it may have missed. When no event has been received for
+// idleTimeout, the watcher assumes the stream has silently stopped and
+// subscribes again. An idleTimeout of 0 disables this.
 func New(
 	routingAPIClient routing_api.Client,
 	updater routing_table.Updater,
 	uaaTokenFetcher uaaclient.TokenFetcher,
-	subscriptionRetryInterval int,
+	clock clock.Clock,
+	retryPolicy retry.Policy,
+	idleTimeout time.Duration,
 	syncChannel chan struct{},
 	logger lager.Logger,
 ) *Watcher {
 	return &Watcher{
-		routingAPIClient:          routingAPIClient,
-		updater:                   updater,
-		uaaTokenFetcher:           uaaTokenFetcher,
-		subscriptionRetryInterval: subscriptionRetryInterval,
-		syncChannel:               syncChannel,
-		logger:                    logger.Session("watcher"),
+		routingAPIClient: routingAPIClient,
+		updater:          updater,
+		uaaTokenFetcher:  uaaTokenFetcher,
+		clock:            clock,
+		backoff:          retry.NewBackoff(retryPolicy, consecutiveSubscriptionFailures),
+		idleTimeout:      idleTimeout,
+		syncChannel:      syncChannel,
+		logger:           logger.Session("watcher"),
 	}
 }
 
@@ -50,9 +81,18 @@ func (watcher *Watcher) Run(signals <-chan os.Signal, ready chan<- struct{}) err
 
 	var eventSource atomic.Value
 	var stopEventSource int32
+	stopped := make(chan struct{})
 	canUseCachedToken := true
+	subscribed := false
 	go func() {
 		var es routing_api.TcpEventSource
+		// when set, events may have been missed since then
+		var disconnectedAt time.Time
+		markDisconnected := func() {
+			if disconnectedAt.IsZero() {
+				disconnectedAt = watcher.clock.Now()
+			}
+		}
 
 		for {
 			if atomic.LoadInt32(&stopEventSource) == 1 {
@@ -61,7 +101,10 @@ func (watcher *Watcher) Run(signals <-chan os.Signal, ready chan<- struct{}) err
 			token, err := watcher.uaaTokenFetcher.FetchToken(context.Background(), !canUseCachedToken)
 			if err != nil {
 				watcher.logger.Error("error-fetching-token", err)
-				time.Sleep(time.Duration(watcher.subscriptionRetryInterval) * time.Second)
+				markDisconnected()
+				if !watcher.waitToRetry(stopped) {
+					return
+				}
 				continue
 			}
 			watcher.routingAPIClient.SetToken(token.AccessToken)
@@ -76,19 +119,43 @@ func (watcher *Watcher) Run(signals <-chan os.Signal, ready chan<- struct{}) err
 					canUseCachedToken = true
 				}
 				watcher.logger.Error("failed-subscribing-to-routing-api-event-stream", err)
-				time.Sleep(time.Duration(watcher.subscriptionRetryInterval) * time.Second)
+				markDisconnected()
+				if !watcher.waitToRetry(stopped) {
+					return
+				}
 				continue
 			} else {
 				canUseCachedToken = true
 			}
+			watcher.backoff.Succeeded()
 			watcher.logger.Info("Successfully-subscribed-to-routing-api-event-stream")
 
+			if subscribed {
+				eventStreamReconnects.Add(1)
+			}
+			subscribed = true
 			eventSource.Store(es)
+			watcher.setConnected(true)
+
+			if !disconnectedAt.IsZero() {
+				gap := watcher.clock.Since(disconnectedAt)
+				watcher.logger.Info("requesting-sync-after-resubscribe", lager.Data{"gap": gap.String()})
+				// #nosec G115 - the gap is never negative
+				eventStreamGap.Send(uint64(gap.Milliseconds()))
+				disconnectedAt = time.Time{}
+				select {
+				case watcher.syncChannel <- struct{}{}:
+				case <-stopped:
+					return
+				}
+			}
 
 			var event routing_api.TcpEvent
 			for {
 				event, err = es.Next()
 				if err != nil {
+					disconnectedAt = time.Unix(0, atomic.LoadInt64(&watcher.lastEventTime))
+					watcher.setConnected(false)
 					watcher.logger.Error("failed-to-get-next-routing-api-event", err)
 					err = es.Close()
 					if err != nil {
@@ -96,16 +163,29 @@ func (watcher *Watcher) Run(signals <-chan os.Signal, ready chan<- struct{}) err
 					}
 					break
 				}
+				watcher.recordEvent()
 				eventChan <- event
 			}
 		}
 	}()
 
+	var idleCheck <-chan time.Time
+	if watcher.idleTimeout > 0 {
+		idleTicker := watcher.clock.NewTicker(watcher.idleTimeout / 4)
+		defer idleTicker.Stop()
+		idleCheck = idleTicker.C()
+	}
+
 	close(ready)
 	watcher.logger.Debug("started")
 
 	for {
 		select {
+		case <-idleCheck:
+			if es := eventSource.Load(); es != nil {
+				watcher.checkIdle(es.(routing_api.TcpEventSource))
+			}
+
 		case event := <-eventChan:
 			// #nosec G104 - the only error this would return is if an unknown event was received and that already gets logged. dont double-log
 			watcher.updater.HandleEvent(event)
@@ -128,6 +208,7 @@ func (watcher *Watcher) Run(signals <-chan os.Signal, ready chan<- struct{}) err
 			} else {
 				watcher.logger.Info("stopping")
 				atomic.StoreInt32(&stopEventSource, 1)
+				close(stopped)
 				if es := eventSource.Load(); es != nil {
 					err := es.(routing_api.TcpEventSource).Close()
 					if err != nil {
@@ -141,6 +222,59 @@ func (watcher *Watcher) Run(signals <-chan os.Signal, ready chan<- struct{}) err
 	}
 }
 
+func (watcher *Watcher) setConnected(connected bool) {
+	if connected {
+		watcher.recordEvent()
+		atomic.StoreInt32(&watcher.connected, 1)
+		eventStreamConnected.Send(1)
+	} else {
+		atomic.StoreInt32(&watcher.connected, 0)
+		eventStreamConnected.Send(0)
+	}
+}
+
+func (watcher *Watcher) recordEvent() {
+	atomic.StoreInt64(&watcher.lastEventTime, watcher.clock.Now().UnixNano())
+}
+
+// checkIdle closes the event source when it has not delivered an event for
+// longer than the idle timeout, which makes the watcher subscribe again.
+func (watcher *Watcher) checkIdle(es routing_api.TcpEventSource) {
+	if atomic.LoadInt32(&watcher.connected) == 0 {
+		return
+	}
+
+	idle := watcher.clock.Since(time.Unix(0, atomic.LoadInt64(&watcher.lastEventTime)))
+	// #nosec G115 - the idle time is never negative
+	eventStreamLag.Send(uint64(idle.Milliseconds()))
+	if idle <= watcher.idleTimeout {
+		return
+	}
+
+	watcher.logger.Info("event-stream-idle", lager.Data{"idle": idle.String(), "idle-timeout": watcher.idleTimeout.String()})
+	eventStreamIdleResubscribes.Add(1)
+	watcher.setConnected(false)
+	err := es.Close()
+	if err != nil {
+		watcher.logger.Error("failed-closing-routing-api-event-source", err)
+	}
+}
+
+// waitToRetry waits for the backoff delay after a failure. It returns false
+// when the watcher is stopped while waiting.
+func (watcher *Watcher) waitToRetry(stopped <-chan struct{}) bool {
+	delay := watcher.backoff.Failed()
+	watcher.logger.Info("retrying-subscription", lager.Data{"delay": delay.String(), "consecutive-failures": watcher.backoff.ConsecutiveFailures()})
+	timer := watcher.clock.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return true
+	case <-stopped:
+		return false
+	}
+}
+
 func (watcher *Watcher) SetProcess(proc ifrit.Process) {
 	watcher.process = proc
 }
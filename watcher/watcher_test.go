@@ -7,12 +7,16 @@ import (
 	"syscall"
 	"time"
 
+	"code.cloudfoundry.org/cf-tcp-router/retry"
 	fake_routing_table "code.cloudfoundry.org/cf-tcp-router/routing_table/fakes"
 	"code.cloudfoundry.org/cf-tcp-router/watcher"
+	"code.cloudfoundry.org/clock/fakeclock"
 	routing_api "code.cloudfoundry.org/routing-api"
 	"code.cloudfoundry.org/routing-api/fake_routing_api"
 	"code.cloudfoundry.org/routing-api/models"
 	test_uaa_client "code.cloudfoundry.org/routing-api/uaaclient/fakes"
+	"github.com/cloudfoundry/dropsonde/metric_sender/fake"
+	"github.com/cloudfoundry/dropsonde/metrics"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/grouper"
 	"github.com/tedsuo/ifrit/sigmon"
@@ -38,9 +42,13 @@ var _ = Describe("Watcher", func() {
 		updater                *fake_routing_table.FakeUpdater
 		processAlreadyShutDown bool
 		signalRecorder         *test_helpers.SignalRecoder
+		retryPolicy            retry.Policy
+		fakeClock              *fakeclock.FakeClock
 	)
 
 	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		retryPolicy = retry.Policy{InitialDelay: time.Second, MaxDelay: time.Second, Multiplier: 1}
 		processAlreadyShutDown = false
 		eventSource = new(fake_routing_api.FakeTcpEventSource)
 		routingApiClient = new(fake_routing_api.FakeClient)
@@ -54,7 +62,7 @@ var _ = Describe("Watcher", func() {
 
 		routingApiClient.SubscribeToTcpEventsReturns(eventSource, nil)
 		syncChannel = make(chan struct{})
-		testWatcher = watcher.New(routingApiClient, updater, uaaTokenFetcher, 1, syncChannel, logger)
+		testWatcher = watcher.New(routingApiClient, updater, uaaTokenFetcher, fakeClock, retryPolicy, 0, syncChannel, logger)
 	})
 
 	JustBeforeEach(func() {
@@ -162,6 +170,91 @@ var _ = Describe("Watcher", func() {
 		})
 	})
 
+	It("does not request a sync after the first subscription", func() {
+		Eventually(routingApiClient.SubscribeToTcpEventsCallCount).Should(Equal(1))
+		Consistently(updater.SyncCallCount).Should(Equal(0))
+	})
+
+	Context("when the event stream is lost", func() {
+		var sender *fake.FakeMetricSender
+
+		BeforeEach(func() {
+			sender = fake.NewFakeMetricSender()
+			metrics.Initialize(sender, nil)
+
+			eventSource.NextReturns(routing_api.TcpEvent{}, errors.New("buzinga.."))
+			newEventSource := new(fake_routing_api.FakeTcpEventSource)
+			newEventSource.NextStub = func() (routing_api.TcpEvent, error) {
+				select {}
+			}
+			routingApiClient.SubscribeToTcpEventsReturnsOnCall(1, newEventSource, nil)
+		})
+
+		It("requests a sync once it has subscribed again", func() {
+			Eventually(routingApiClient.SubscribeToTcpEventsCallCount, 5*time.Second).Should(Equal(2))
+			Eventually(logger).Should(gbytes.Say("requesting-sync-after-resubscribe"))
+			Eventually(updater.SyncCallCount).Should(Equal(1))
+			Expect(sender.GetValue("EventStreamGapMs").Unit).To(Equal("ms"))
+		})
+	})
+
+	Context("when the event stream stops delivering events", func() {
+		const idleTimeout = 2 * time.Minute
+
+		var sender *fake.FakeMetricSender
+
+		BeforeEach(func() {
+			sender = fake.NewFakeMetricSender()
+			metrics.Initialize(sender, nil)
+
+			closed := make(chan struct{})
+			eventSource.NextStub = func() (routing_api.TcpEvent, error) {
+				<-closed
+				return routing_api.TcpEvent{}, errors.New("closed")
+			}
+			eventSource.CloseStub = func() error {
+				select {
+				case <-closed:
+				default:
+					close(closed)
+				}
+				return nil
+			}
+			newEventSource := new(fake_routing_api.FakeTcpEventSource)
+			newEventSource.NextStub = func() (routing_api.TcpEvent, error) {
+				select {}
+			}
+			routingApiClient.SubscribeToTcpEventsReturnsOnCall(1, newEventSource, nil)
+
+			testWatcher = watcher.New(routingApiClient, updater, uaaTokenFetcher, fakeClock, retryPolicy, idleTimeout, syncChannel, logger)
+		})
+
+		It("reports the stream as connected", func() {
+			Eventually(func() float64 { return sender.GetValue("EventStreamConnected").Value }).Should(Equal(float64(1)))
+		})
+
+		It("reports the time since the last event", func() {
+			Eventually(routingApiClient.SubscribeToTcpEventsCallCount).Should(Equal(1))
+			fakeClock.WaitForWatcherAndIncrement(idleTimeout / 4)
+			Eventually(func() float64 { return sender.GetValue("EventStreamLagMs").Value }).Should(Equal(float64((idleTimeout / 4).Milliseconds())))
+			Expect(eventSource.CloseCallCount()).To(Equal(0))
+		})
+
+		It("subscribes again and syncs once the idle timeout is exceeded", func() {
+			Eventually(func() float64 { return sender.GetValue("EventStreamConnected").Value }).Should(Equal(float64(1)))
+			for i := 0; i < 5; i++ {
+				fakeClock.WaitForWatcherAndIncrement(idleTimeout / 4)
+			}
+
+			Eventually(logger).Should(gbytes.Say("event-stream-idle"))
+			Eventually(eventSource.CloseCallCount).Should(Equal(2))
+			Eventually(updater.SyncCallCount).Should(Equal(1))
+			Eventually(routingApiClient.SubscribeToTcpEventsCallCount, 5*time.Second).Should(Equal(2))
+			Expect(sender.GetCounter("EventStreamIdleResubscribes")).To(Equal(uint64(1)))
+			Eventually(func() uint64 { return sender.GetCounter("EventStreamReconnects") }).Should(Equal(uint64(1)))
+		})
+	})
+
 	Context("when subscribe to events fails", func() {
 		var (
 			routingApiErrChannel chan error
@@ -177,7 +270,7 @@ var _ = Describe("Watcher", func() {
 				return eventSource, nil
 			}
 
-			testWatcher = watcher.New(routingApiClient, updater, uaaTokenFetcher, 1, syncChannel, logger)
+			testWatcher = watcher.New(routingApiClient, updater, uaaTokenFetcher, fakeClock, retryPolicy, 0, syncChannel, logger)
 		})
 
 		Context("with error other than unauthorized", func() {
@@ -187,35 +280,70 @@ var _ = Describe("Watcher", func() {
 				Expect(forceUpdate).To(BeFalse())
 				routingApiErrChannel <- errors.New("kaboom")
 				close(routingApiErrChannel)
-				Eventually(routingApiClient.SubscribeToTcpEventsCallCount, 5*time.Second, 1*time.Second).Should(Equal(2))
+				fakeClock.WaitForWatcherAndIncrement(time.Second)
+				Eventually(routingApiClient.SubscribeToTcpEventsCallCount).Should(Equal(2))
 				Eventually(logger).Should(gbytes.Say("failed-subscribing-to-routing-api-event-stream"))
-				Eventually(uaaTokenFetcher.FetchTokenCallCount, 5*time.Second, 1*time.Second).Should(Equal(2))
+				Eventually(uaaTokenFetcher.FetchTokenCallCount).Should(Equal(2))
 				_, forceUpdate = uaaTokenFetcher.FetchTokenArgsForCall(1)
 				Expect(forceUpdate).To(BeFalse())
 			})
 		})
 
+		It("reports consecutive failures until it subscribes", func() {
+			sender := fake.NewFakeMetricSender()
+			metrics.Initialize(sender, nil)
+
+			routingApiErrChannel <- errors.New("kaboom")
+			Eventually(logger).Should(gbytes.Say("retrying-subscription"))
+			Expect(sender.GetValue("ConsecutiveSubscriptionFailures").Value).To(Equal(float64(1)))
+
+			close(routingApiErrChannel)
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(routingApiClient.SubscribeToTcpEventsCallCount).Should(Equal(2))
+			Eventually(func() float64 { return sender.GetValue("ConsecutiveSubscriptionFailures").Value }).Should(Equal(float64(0)))
+		})
+
+		It("requests a sync once it has subscribed", func() {
+			routingApiErrChannel <- errors.New("kaboom")
+			close(routingApiErrChannel)
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(routingApiClient.SubscribeToTcpEventsCallCount).Should(Equal(2))
+			Eventually(logger).Should(gbytes.Say("requesting-sync-after-resubscribe"))
+			Eventually(updater.SyncCallCount).Should(Equal(1))
+		})
+
 		Context("with unauthorized error", func() {
 			It("fetches a new token and retries to subscribe", func() {
 				Eventually(uaaTokenFetcher.FetchTokenCallCount, 5*time.Second, 1*time.Second).Should(Equal(1))
 				_, forceUpdate := uaaTokenFetcher.FetchTokenArgsForCall(0)
 				Expect(forceUpdate).To(BeFalse())
 				routingApiErrChannel <- errors.New("unauthorized")
-				Eventually(routingApiClient.SubscribeToTcpEventsCallCount, 5*time.Second, 1*time.Second).Should(Equal(2))
+				fakeClock.WaitForWatcherAndIncrement(time.Second)
+				Eventually(routingApiClient.SubscribeToTcpEventsCallCount).Should(Equal(2))
 				Eventually(logger).Should(gbytes.Say("failed-subscribing-to-routing-api-event-stream"))
-				Eventually(uaaTokenFetcher.FetchTokenCallCount, 5*time.Second, 1*time.Second).Should(Equal(2))
+				Eventually(uaaTokenFetcher.FetchTokenCallCount).Should(Equal(2))
 				_, forceUpdate = uaaTokenFetcher.FetchTokenArgsForCall(1)
 				Expect(forceUpdate).To(BeTrue())
 
 				By("resumes to use cache token for subsequent errors")
 				routingApiErrChannel <- errors.New("kaboom")
 				close(routingApiErrChannel)
-				Eventually(routingApiClient.SubscribeToTcpEventsCallCount, 5*time.Second, 1*time.Second).Should(Equal(3))
+				fakeClock.WaitForWatcherAndIncrement(time.Second)
+				Eventually(routingApiClient.SubscribeToTcpEventsCallCount).Should(Equal(3))
 				Eventually(logger).Should(gbytes.Say("failed-subscribing-to-routing-api-event-stream"))
-				Eventually(uaaTokenFetcher.FetchTokenCallCount, 5*time.Second, 1*time.Second).Should(Equal(3))
+				Eventually(uaaTokenFetcher.FetchTokenCallCount).Should(Equal(3))
 				_, forceUpdate = uaaTokenFetcher.FetchTokenArgsForCall(2)
 				Expect(forceUpdate).To(BeFalse())
 			})
+
+			It("requests a sync once it has subscribed with the new token", func() {
+				routingApiErrChannel <- errors.New("unauthorized")
+				close(routingApiErrChannel)
+				fakeClock.WaitForWatcherAndIncrement(time.Second)
+				Eventually(routingApiClient.SubscribeToTcpEventsCallCount).Should(Equal(2))
+				Eventually(logger).Should(gbytes.Say("requesting-sync-after-resubscribe"))
+				Eventually(updater.SyncCallCount).Should(Equal(1))
+			})
 		})
 	})
 
@@ -226,7 +354,28 @@ var _ = Describe("Watcher", func() {
 
 		It("returns an error", func() {
 			Eventually(logger).Should(gbytes.Say("error-fetching-token"))
-			Eventually(uaaTokenFetcher.FetchTokenCallCount, 5*time.Second, 1*time.Second).Should(BeNumerically(">", 2))
+			for i := 0; i < 2; i++ {
+				fakeClock.WaitForWatcherAndIncrement(time.Second)
+			}
+			Eventually(uaaTokenFetcher.FetchTokenCallCount).Should(BeNumerically(">", 2))
+		})
+
+		It("requests a sync once it has subscribed", func() {
+			Eventually(logger).Should(gbytes.Say("error-fetching-token"))
+			uaaTokenFetcher.FetchTokenReturns(&oauth2.Token{AccessToken: "access_token"}, nil)
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(routingApiClient.SubscribeToTcpEventsCallCount).Should(Equal(1))
+			Eventually(updater.SyncCallCount).Should(Equal(1))
+		})
+
+		It("stops retrying when the watcher is stopped", func() {
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive())
+			processAlreadyShutDown = true
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(0))
+			Expect(uaaTokenFetcher.FetchTokenCallCount()).To(Equal(1))
 		})
 	})
 